@@ -1,16 +1,23 @@
 package vcd
 
 import (
+	"context"
 	"net/url"
 	"sync"
+	"time"
 
+	"github.com/hashicorp/terraform/helper/schema"
 	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
 	"github.com/pkg/errors"
 )
 
 type Config struct {
+	AuthType        string
 	User            string
 	Password        string
+	Token           string
+	APIToken        string
 	Org             string
 	Href            string
 	VDC             string
@@ -22,12 +29,26 @@ type Config struct {
 type VCDClient struct {
 	*govcd.VCDClient
 
+	// The org and the VDC of the provider, used by the resources which do
+	// not override them
 	Org    govcd.Org
 	OrgVdc govcd.Vdc
 
-	Mutex           sync.Mutex
-	MaxRetryTimeout int
-	InsecureFlag    bool
+	Mutex        sync.Mutex
+	InsecureFlag bool
+
+	// Bounds the retries of the operations when set, the operations are
+	// still bounded by the timeouts of the resources
+	maxRetryTimeout time.Duration
+
+	// Ends when Terraform is interrupted, the waits for tasks end with it
+	stopContext context.Context
+
+	// The orgs and the VDCs the resources override the provider ones with,
+	// they are read on first use
+	cacheMutex sync.Mutex
+	orgs       map[string]*govcd.Org
+	vdcs       map[string]*govcd.Vdc
 }
 
 func (c *Config) Client() (*VCDClient, error) {
@@ -36,10 +57,31 @@ func (c *Config) Client() (*VCDClient, error) {
 		return nil, errors.Wrapf(err, "Cannot parse URL: %s", c.Href)
 	}
 
+	err = c.validateAuth()
+	if err != nil {
+		return nil, err
+	}
+
 	client := govcd.NewVCDClient(*u, c.InsecureFlag, c.ApiVersion)
+
+	// The transport authenticates the requests and logs in again when the
+	// session expires, the idempotent requests are sent again while vCD is
+	// unavailable
+	transport := &authTransport{
+		base:     &retryTransport{base: client.Client.Http.Transport},
+		authType: c.AuthType,
+		token:    c.Token,
+		apiToken: c.APIToken,
+		org:      c.Org,
+	}
+	client.Client.Http.Transport = transport
+
 	err = client.Authenticate(c.User, c.Password, c.Org)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Cannot authenticate in vCD: orgName=%s, userName=%s", c.Org, c.User)
+		return nil, errors.Wrapf(err, "Cannot authenticate in vCD: authType=%s, orgName=%s, userName=%s", c.AuthType, c.Org, c.User)
+	}
+	transport.login = func() error {
+		return client.Authenticate(c.User, c.Password, c.Org)
 	}
 
 	org, err := client.GetOrg()
@@ -53,11 +95,175 @@ func (c *Config) Client() (*VCDClient, error) {
 	}
 
 	return &VCDClient{
-		client,
-		org,
-		vdc,
-		sync.Mutex{},
-		c.MaxRetryTimeout,
-		c.InsecureFlag,
+		VCDClient:       client,
+		Org:             org,
+		OrgVdc:          vdc,
+		InsecureFlag:    c.InsecureFlag,
+		maxRetryTimeout: time.Duration(c.MaxRetryTimeout) * time.Second,
+		orgs:            map[string]*govcd.Org{},
+		vdcs:            map[string]*govcd.Vdc{},
 	}, nil
 }
+
+// orgFromResource returns the org of the resource, the provider org is used
+// when the resource does not override it
+func (c *VCDClient) orgFromResource(d *schema.ResourceData) (*govcd.Org, error) {
+	orgName, _ := d.Get("org").(string)
+	return c.findOrg(orgName)
+}
+
+// vdcFromResource returns the VDC of the resource. A VDC of the same name as
+// the provider VDC is used when the resource overrides the org only.
+func (c *VCDClient) vdcFromResource(d *schema.ResourceData) (*govcd.Vdc, error) {
+	orgName, _ := d.Get("org").(string)
+	vdcName, _ := d.Get("vdc").(string)
+	return c.findVdc(orgName, vdcName)
+}
+
+// adminOrgFromResource returns the admin view of the org of the resource,
+// used for create, update and delete operations
+func (c *VCDClient) adminOrgFromResource(d *schema.ResourceData) (*govcd.AdminOrg, error) {
+	org, err := c.orgFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	adminOrgHREF, err := org.Org.Link.URLForType(types.MimeAdminOrg, types.RelAlternate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot find AdminOrg link: orgName=%s", org.Org.Name)
+	}
+
+	adminOrg := govcd.NewAdminOrg(&c.Client)
+	adminOrg.AdminOrg.HREF = adminOrgHREF.String()
+	err = adminOrg.Refresh()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot retrieve AdminOrg: orgName=%s", org.Org.Name)
+	}
+	return adminOrg, nil
+}
+
+// findOrg returns the org with the given name, an empty name is the provider
+// org. The org is a copy of the cached one, so that it can be refreshed while
+// other resources use it.
+func (c *VCDClient) findOrg(orgName string) (*govcd.Org, error) {
+	if orgName == "" || orgName == c.Org.Org.Name {
+		org := c.Org
+		return &org, nil
+	}
+
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if cached, ok := c.orgs[orgName]; ok {
+		org := *cached
+		return &org, nil
+	}
+
+	orgList := &types.OrgList{}
+	u := c.Client.VCDEndpoint
+	u.Path += "/org/"
+	err := getXML(&c.Client, u.String(), orgList)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot retrieve Org list")
+	}
+
+	for _, reference := range orgList.Org {
+		if reference.Name != orgName {
+			continue
+		}
+
+		org := govcd.NewOrg(&c.Client)
+		org.Org.HREF = reference.HREF
+		err = org.Refresh()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Cannot retrieve Org: orgName=%s", orgName)
+		}
+
+		c.orgs[orgName] = org
+		copied := *org
+		return &copied, nil
+	}
+
+	return nil, errors.Errorf("Cannot find Org: orgName=%s", orgName)
+}
+
+// findVdc returns the VDC with the given name of the given org, empty names
+// are the ones of the provider. The VDC is a copy of the cached one like the
+// org of findOrg.
+func (c *VCDClient) findVdc(orgName, vdcName string) (*govcd.Vdc, error) {
+	if orgName == "" {
+		orgName = c.Org.Org.Name
+	}
+	if vdcName == "" {
+		vdcName = c.OrgVdc.Vdc.Name
+	}
+	if orgName == c.Org.Org.Name && vdcName == c.OrgVdc.Vdc.Name {
+		vdc := c.OrgVdc
+		return &vdc, nil
+	}
+
+	org, err := c.findOrg(orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	key := orgName + "/" + vdcName
+	if cached, ok := c.vdcs[key]; ok {
+		vdc := *cached
+		return &vdc, nil
+	}
+
+	vdc, err := org.FindVDC(vdcName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot retrieve VDC: orgName=%s, vdcName=%s", orgName, vdcName)
+	}
+
+	cached := vdc
+	c.vdcs[key] = &cached
+	return &vdc, nil
+}
+
+// orgSchema is the org argument overriding the provider org
+func orgSchema(forceNew bool) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		ForceNew:    forceNew,
+		Description: "The name of the org, the provider org is used when not set",
+	}
+}
+
+// vdcSchema is the vdc argument overriding the provider VDC
+func vdcSchema(forceNew bool) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		ForceNew:    forceNew,
+		Description: "The name of the VDC, the provider VDC is used when not set",
+	}
+}
+
+// validateAuth checks that the credentials of the authentication type are set
+func (c *Config) validateAuth() error {
+	switch c.AuthType {
+	case "", authTypePassword:
+		c.AuthType = authTypePassword
+		if c.User == "" || c.Password == "" {
+			return errors.Errorf("user and password are required for the %s authentication", authTypePassword)
+		}
+	case authTypeToken:
+		if c.Token == "" {
+			return errors.Errorf("token is required for the %s authentication", authTypeToken)
+		}
+	case authTypeAPIToken:
+		if c.APIToken == "" {
+			return errors.Errorf("api_token is required for the %s authentication", authTypeAPIToken)
+		}
+	default:
+		return errors.Errorf("unsupported authentication type: %s", c.AuthType)
+	}
+	return nil
+}
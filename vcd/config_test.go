@@ -0,0 +1,78 @@
+package vcd
+
+import (
+	"testing"
+
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func TestFindVdcDefaults(t *testing.T) {
+	vcdClient := &VCDClient{
+		Org:    govcd.Org{Org: &types.Org{Name: "org"}},
+		OrgVdc: govcd.Vdc{Vdc: &types.Vdc{Name: "vdc"}},
+	}
+
+	cases := []struct {
+		orgName string
+		vdcName string
+	}{
+		{"", ""},
+		{"org", ""},
+		{"", "vdc"},
+		{"org", "vdc"},
+	}
+
+	for _, c := range cases {
+		vdc, err := vcdClient.findVdc(c.orgName, c.vdcName)
+		if err != nil {
+			t.Fatalf("%s/%s: unexpected error: %v", c.orgName, c.vdcName, err)
+		}
+		if vdc.Vdc != vcdClient.OrgVdc.Vdc {
+			t.Errorf("%s/%s: expected the provider VDC", c.orgName, c.vdcName)
+		}
+		if vdc == &vcdClient.OrgVdc {
+			t.Errorf("%s/%s: expected a copy of the provider VDC", c.orgName, c.vdcName)
+		}
+	}
+
+	org, err := vcdClient.findOrg("")
+	if err != nil || org.Org != vcdClient.Org.Org {
+		t.Errorf("expected the provider org, got %v", err)
+	}
+	if org == &vcdClient.Org {
+		t.Errorf("expected a copy of the provider org")
+	}
+}
+
+func TestFindVdcCopies(t *testing.T) {
+	vcdClient := &VCDClient{
+		Org:    govcd.Org{Org: &types.Org{Name: "org"}},
+		OrgVdc: govcd.Vdc{Vdc: &types.Vdc{Name: "vdc"}},
+		orgs:   map[string]*govcd.Org{"other": {Org: &types.Org{Name: "other"}}},
+		vdcs:   map[string]*govcd.Vdc{"other/vdc": {Vdc: &types.Vdc{Name: "vdc"}}},
+	}
+
+	// A refresh replaces the content of the copy only
+	vdc, err := vcdClient.findVdc("other", "vdc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vdc.Vdc = &types.Vdc{Name: "refreshed"}
+
+	vdc, err = vcdClient.findVdc("other", "vdc")
+	if err != nil || vdc.Vdc.Name != "vdc" {
+		t.Errorf("expected the cached VDC to be left alone, got %v %v", vdc, err)
+	}
+
+	org, err := vcdClient.findOrg("other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	org.Org = &types.Org{Name: "refreshed"}
+
+	org, err = vcdClient.findOrg("other")
+	if err != nil || org.Org.Name != "other" {
+		t.Errorf("expected the cached org to be left alone, got %v %v", org, err)
+	}
+}
@@ -0,0 +1,75 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdCatalog() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdCatalogRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_published": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"catalog_items": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceVcdCatalogRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = org.Refresh()
+	if err != nil {
+		return fmt.Errorf("error refreshing org: %#v", err)
+	}
+
+	catalog, err := org.FindCatalog(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	catalogItems := make([]string, 0)
+	for _, items := range catalog.Catalog.CatalogItems {
+		catalogItems = append(catalogItems, referenceNames(items.CatalogItem)...)
+	}
+
+	d.SetId(catalog.Catalog.HREF)
+	d.Set("description", catalog.Catalog.Description)
+	d.Set("is_published", catalog.Catalog.IsPublished)
+	d.Set("created", catalog.Catalog.DateCreated)
+	d.Set("href", catalog.Catalog.HREF)
+	d.Set("catalog_items", catalogItems)
+
+	return nil
+}
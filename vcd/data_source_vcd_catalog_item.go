@@ -0,0 +1,92 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+)
+
+func dataSourceVcdCatalogItem() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdCatalogItemRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"catalog_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"entity_href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"entity_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceVcdCatalogItemRead(d *schema.ResourceData, meta interface{}) error {
+	catalogItem, err := findCatalogItem(d, meta)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(catalogItem.CatalogItem.HREF)
+	d.Set("description", catalogItem.CatalogItem.Description)
+	d.Set("created", catalogItem.CatalogItem.DateCreated)
+	d.Set("href", catalogItem.CatalogItem.HREF)
+	if catalogItem.CatalogItem.Entity != nil {
+		d.Set("entity_href", catalogItem.CatalogItem.Entity.HREF)
+		d.Set("entity_type", catalogItem.CatalogItem.Entity.Type)
+	}
+
+	return nil
+}
+
+// findCatalogItem looks up the catalog item named by the catalog_name and
+// name attributes
+func findCatalogItem(d *schema.ResourceData, meta interface{}) (govcd.CatalogItem, error) {
+	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return govcd.CatalogItem{}, err
+	}
+
+	err = org.Refresh()
+	if err != nil {
+		return govcd.CatalogItem{}, fmt.Errorf("error refreshing org: %#v", err)
+	}
+
+	catalog, err := org.FindCatalog(d.Get("catalog_name").(string))
+	if err != nil {
+		return govcd.CatalogItem{}, fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	catalogItem, err := catalog.FindCatalogItem(d.Get("name").(string))
+	if err != nil {
+		return govcd.CatalogItem{}, fmt.Errorf("Error finding catalog item: %#v", err)
+	}
+
+	return catalogItem, nil
+}
@@ -0,0 +1,51 @@
+package vcd
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdCatalogItems() *schema.Resource {
+	q := &queryDataSource{
+		queryType: "vAppTemplate",
+		attribute: "catalog_items",
+		record: map[string]schema.ValueType{
+			"name":            schema.TypeString,
+			"href":            schema.TypeString,
+			"catalog_name":    schema.TypeString,
+			"description":     schema.TypeString,
+			"vdc_name":        schema.TypeString,
+			"status":          schema.TypeString,
+			"storage_profile": schema.TypeString,
+			"number_of_vms":   schema.TypeInt,
+			"owner_name":      schema.TypeString,
+			"creation_date":   schema.TypeString,
+			"is_in_catalog":   schema.TypeBool,
+			"is_published":    schema.TypeBool,
+		},
+		flatten: flattenCatalogItemRecords,
+	}
+
+	return q.Resource()
+}
+
+func flattenCatalogItemRecords(results *types.QueryResultRecordsType) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(results.VAppTemplateRecord))
+	for _, record := range results.VAppTemplateRecord {
+		records = append(records, map[string]interface{}{
+			"name":            record.Name,
+			"href":            record.HREF,
+			"catalog_name":    record.CatalogName,
+			"description":     record.Description,
+			"vdc_name":        record.VdcName,
+			"status":          record.Status,
+			"storage_profile": record.StorageProfileName,
+			"number_of_vms":   record.NumberOfVMs,
+			"owner_name":      record.OwnerName,
+			"creation_date":   record.CreationDate,
+			"is_in_catalog":   record.IsInCatalog,
+			"is_published":    record.IsPublished,
+		})
+	}
+	return records
+}
@@ -0,0 +1,104 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/units"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdDisk() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdDiskRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size_bytes": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"iops": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"bus_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bus_sub_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"storage_profile": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"attached_vm_href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceVcdDiskRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	disk, err := vdc.FindDiskByName(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding disk: %#v", err)
+	}
+
+	attachedVM, err := disk.AttachedVM()
+	if err != nil {
+		return fmt.Errorf("Error finding VM attached to disk: %#v", err)
+	}
+
+	d.SetId(disk.Disk.HREF)
+	d.Set("description", disk.Disk.Description)
+	d.Set("size", units.Base2Bytes(disk.Disk.Size).String())
+	d.Set("size_bytes", disk.Disk.Size)
+	if disk.Disk.Iops != nil {
+		d.Set("iops", *disk.Disk.Iops)
+	}
+	d.Set("bus_type", disk.Disk.BusType)
+	d.Set("bus_sub_type", disk.Disk.BusSubType)
+	if disk.Disk.StorageProfile != nil {
+		d.Set("storage_profile", disk.Disk.StorageProfile.Name)
+	}
+	d.Set("href", disk.Disk.HREF)
+	if attachedVM != nil {
+		d.Set("attached_vm_href", attachedVM.HREF)
+	} else {
+		d.Set("attached_vm_href", "")
+	}
+
+	return nil
+}
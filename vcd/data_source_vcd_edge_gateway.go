@@ -0,0 +1,141 @@
+package vcd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdEdgeGateway() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdEdgeGatewayRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"backing_config": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ha_enabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"external_ips": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"interface": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"display_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"network": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ip_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"gateway": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"netmask": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"use_for_default_route": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdEdgeGatewayRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	externalIPs := make([]string, 0)
+	interfaces := make([]map[string]interface{}, 0)
+
+	configuration := edgeGateway.EdgeGateway.Configuration
+	if configuration != nil && configuration.GatewayInterfaces != nil {
+		for _, gatewayInterface := range configuration.GatewayInterfaces.GatewayInterface {
+			readInterface := map[string]interface{}{
+				"name":                  gatewayInterface.Name,
+				"display_name":          gatewayInterface.DisplayName,
+				"type":                  gatewayInterface.InterfaceType,
+				"use_for_default_route": gatewayInterface.UseForDefaultRoute,
+			}
+
+			if gatewayInterface.Network != nil {
+				readInterface["network"] = gatewayInterface.Network.Name
+			}
+
+			if subnet := gatewayInterface.SubnetParticipation; subnet != nil {
+				readInterface["ip_address"] = subnet.IPAddress
+				readInterface["gateway"] = subnet.Gateway
+				readInterface["netmask"] = subnet.Netmask
+
+				if strings.EqualFold(gatewayInterface.InterfaceType, "uplink") && subnet.IPAddress != "" {
+					externalIPs = append(externalIPs, subnet.IPAddress)
+				}
+			}
+
+			interfaces = append(interfaces, readInterface)
+		}
+	}
+
+	d.SetId(edgeGateway.EdgeGateway.HREF)
+	d.Set("description", edgeGateway.EdgeGateway.Description)
+	d.Set("href", edgeGateway.EdgeGateway.HREF)
+	if configuration != nil {
+		d.Set("backing_config", configuration.GatewayBackingConfig)
+		d.Set("ha_enabled", configuration.HaEnabled)
+	}
+	d.Set("external_ips", externalIPs)
+	d.Set("interface", interfaces)
+
+	return nil
+}
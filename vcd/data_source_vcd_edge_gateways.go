@@ -0,0 +1,43 @@
+package vcd
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdEdgeGateways() *schema.Resource {
+	q := &queryDataSource{
+		queryType: "edgeGateway",
+		attribute: "edge_gateways",
+		record: map[string]schema.ValueType{
+			"name":                   schema.TypeString,
+			"href":                   schema.TypeString,
+			"vdc_href":               schema.TypeString,
+			"gateway_status":         schema.TypeString,
+			"ha_status":              schema.TypeString,
+			"number_of_ext_networks": schema.TypeInt,
+			"number_of_org_networks": schema.TypeInt,
+			"busy":                   schema.TypeBool,
+		},
+		flatten: flattenEdgeGatewayRecords,
+	}
+
+	return q.Resource()
+}
+
+func flattenEdgeGatewayRecords(results *types.QueryResultRecordsType) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(results.EdgeGatewayRecord))
+	for _, record := range results.EdgeGatewayRecord {
+		records = append(records, map[string]interface{}{
+			"name":                   record.Name,
+			"href":                   record.HREF,
+			"vdc_href":               record.Vdc,
+			"gateway_status":         record.GatewayStatus,
+			"ha_status":              record.HaStatus,
+			"number_of_ext_networks": record.NumberOfExtNetworks,
+			"number_of_org_networks": record.NumberOfOrgNetworks,
+			"busy":                   record.IsBusy,
+		})
+	}
+	return records
+}
@@ -0,0 +1,146 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+)
+
+func dataSourceVcdNetwork() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdNetworkRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fence_mode": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"edge_gateway": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"parent_network": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"netmask": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"gateway": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"dns1": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"dns2": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"dns_suffix": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"shared": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"static_ip_pool": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"start_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"end_address": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdNetworkRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	network, err := vdc.FindVDCNetwork(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding network: %#v", err)
+	}
+
+	d.SetId(network.OrgVDCNetwork.HREF)
+	d.Set("description", network.OrgVDCNetwork.Description)
+	d.Set("shared", network.OrgVDCNetwork.IsShared)
+	d.Set("href", network.OrgVDCNetwork.HREF)
+
+	if c := network.OrgVDCNetwork.Configuration; c != nil {
+		d.Set("fence_mode", c.FenceMode)
+		if c.ParentNetwork != nil {
+			d.Set("parent_network", c.ParentNetwork.Name)
+		}
+		if c.IPScopes != nil && len(c.IPScopes.IPScope) > 0 {
+			ipScope := c.IPScopes.IPScope[0]
+			d.Set("gateway", ipScope.Gateway)
+			d.Set("netmask", ipScope.Netmask)
+			d.Set("dns1", ipScope.DNS1)
+			d.Set("dns2", ipScope.DNS2)
+			d.Set("dns_suffix", ipScope.DNSSuffix)
+
+			staticIPPool := make([]interface{}, 0)
+			if ipScope.IPRanges != nil {
+				for _, ipRange := range ipScope.IPRanges.IPRange {
+					staticIPPool = append(staticIPPool, map[string]interface{}{
+						"start_address": ipRange.StartAddress,
+						"end_address":   ipRange.EndAddress,
+					})
+				}
+			}
+			d.Set("static_ip_pool", staticIPPool)
+		}
+	}
+
+	if network.OrgVDCNetwork.EdgeGateway != nil {
+		edgeGateway := govcd.NewEdgeGateway(&vcdClient.Client)
+		edgeGateway.EdgeGateway.HREF = network.OrgVDCNetwork.EdgeGateway.HREF
+		err = edgeGateway.Refresh()
+		if err != nil {
+			return fmt.Errorf("Error finding edge gateway: %#v", err)
+		}
+
+		d.Set("edge_gateway", edgeGateway.EdgeGateway.Name)
+	}
+
+	return nil
+}
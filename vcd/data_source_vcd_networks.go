@@ -0,0 +1,53 @@
+package vcd
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdNetworks() *schema.Resource {
+	q := &queryDataSource{
+		queryType: "orgVdcNetwork",
+		attribute: "networks",
+		record: map[string]schema.ValueType{
+			"name":         schema.TypeString,
+			"href":         schema.TypeString,
+			"vdc_name":     schema.TypeString,
+			"vdc_href":     schema.TypeString,
+			"connected_to": schema.TypeString,
+			"gateway":      schema.TypeString,
+			"netmask":      schema.TypeString,
+			"dns1":         schema.TypeString,
+			"dns2":         schema.TypeString,
+			"dns_suffix":   schema.TypeString,
+			"shared":       schema.TypeBool,
+			"busy":         schema.TypeBool,
+			"link_type":    schema.TypeInt,
+		},
+		flatten: flattenNetworkRecords,
+	}
+
+	return q.Resource()
+}
+
+func flattenNetworkRecords(results *types.QueryResultRecordsType) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(results.OrgVdcNetworkRecord))
+	for _, record := range results.OrgVdcNetworkRecord {
+		records = append(records, map[string]interface{}{
+			"name":         record.Name,
+			"href":         record.HREF,
+			"vdc_name":     record.VdcName,
+			"vdc_href":     record.Vdc,
+			"connected_to": record.ConnectedTo,
+			"gateway":      record.DefaultGateway,
+			"netmask":      record.Netmask,
+			"dns1":         record.Dns1,
+			"dns2":         record.Dns2,
+			"dns_suffix":   record.DnsSuffix,
+			"shared":       record.IsShared,
+			"busy":         record.IsBusy,
+			"link_type":    record.LinkType,
+		})
+	}
+	return records
+}
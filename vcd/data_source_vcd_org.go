@@ -0,0 +1,95 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdOrg() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdOrgRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"full_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_enabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vdcs": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"catalogs": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceVcdOrgRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	o, err := vcdClient.findOrg(d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+
+	err = o.Refresh()
+	if err != nil {
+		return fmt.Errorf("error refreshing org: %#v", err)
+	}
+
+	org := o.Org
+
+	d.SetId(org.HREF)
+	d.Set("name", org.Name)
+	d.Set("full_name", org.FullName)
+	d.Set("description", org.Description)
+	d.Set("is_enabled", org.IsEnabled)
+	d.Set("href", org.HREF)
+	d.Set("vdcs", linkNames(org.Link, types.MimeVDC))
+	d.Set("catalogs", linkNames(org.Link, types.MimeCatalog))
+
+	return nil
+}
+
+// linkNames returns the names of the child links of the given type
+func linkNames(links types.LinkList, tpe string) []string {
+	names := make([]string, 0)
+	for _, link := range links {
+		if link.Type == tpe && link.Rel == types.RelDown {
+			names = append(names, link.Name)
+		}
+	}
+	return names
+}
+
+// referenceNames returns the names of the references
+func referenceNames(references types.ReferenceList) []string {
+	names := make([]string, 0, len(references))
+	for _, reference := range references {
+		names = append(names, reference.Name)
+	}
+	return names
+}
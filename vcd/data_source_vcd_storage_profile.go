@@ -0,0 +1,78 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/pkg/errors"
+)
+
+func dataSourceVcdStorageProfile() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdStorageProfileRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			// The default storage profile of the vdc is used when no name is given
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"is_default": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceVcdStorageProfileRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	storageProfileName := d.Get("name").(string)
+	isDefault := storageProfileName == ""
+	if isDefault {
+		storageProfileName, err = findDefaultStorageProfile(vcdClient, vdc)
+		if err != nil {
+			return errors.Wrapf(err, "cannot find default storage profile")
+		}
+	}
+
+	storageProfile, err := vdc.FindStorageProfileReference(storageProfileName)
+	if err != nil {
+		return err
+	}
+
+	// A storage profile given by name tells whether it is the default one
+	if !isDefault {
+		vdcStorageProfile := &vdcStorageProfile{}
+		err = getXML(&vcdClient.Client, storageProfile.HREF, vdcStorageProfile)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read storage profile: %s", storageProfileName)
+		}
+		isDefault = vdcStorageProfile.Default
+	}
+
+	d.SetId(storageProfile.HREF)
+	d.Set("name", storageProfile.Name)
+	d.Set("is_default", isDefault)
+	d.Set("href", storageProfile.HREF)
+
+	return nil
+}
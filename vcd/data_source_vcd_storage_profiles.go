@@ -0,0 +1,43 @@
+package vcd
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdStorageProfiles() *schema.Resource {
+	q := &queryDataSource{
+		queryType: "orgVdcStorageProfile",
+		attribute: "storage_profiles",
+		record: map[string]schema.ValueType{
+			"name":             schema.TypeString,
+			"href":             schema.TypeString,
+			"vdc_name":         schema.TypeString,
+			"vdc_href":         schema.TypeString,
+			"is_default":       schema.TypeBool,
+			"is_enabled":       schema.TypeBool,
+			"storage_used_mb":  schema.TypeInt,
+			"storage_limit_mb": schema.TypeInt,
+		},
+		flatten: flattenStorageProfileRecords,
+	}
+
+	return q.Resource()
+}
+
+func flattenStorageProfileRecords(results *types.QueryResultRecordsType) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(results.OrgVdcStorageProfileRecord))
+	for _, record := range results.OrgVdcStorageProfileRecord {
+		records = append(records, map[string]interface{}{
+			"name":             record.Name,
+			"href":             record.HREF,
+			"vdc_name":         record.VdcName,
+			"vdc_href":         record.VdcHREF,
+			"is_default":       record.IsDefaultStorageProfile,
+			"is_enabled":       record.IsEnabled,
+			"storage_used_mb":  record.StorageUsedMB,
+			"storage_limit_mb": record.StorageLimitMB,
+		})
+	}
+	return records
+}
@@ -0,0 +1,82 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdVApp() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdVAppRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"deployed": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"networks": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"vm": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     vAppVMSchema(),
+			},
+		},
+	}
+}
+
+func dataSourceVcdVAppRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	vapp, err := vdc.FindVAppByName(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding VApp: %#v", err)
+	}
+
+	networks := make([]string, 0)
+	if vapp.VApp.NetworkConfigSection != nil {
+		for _, networkConfig := range vapp.VApp.NetworkConfigSection.NetworkConfig {
+			if networkConfig.NetworkName != "none" {
+				networks = append(networks, networkConfig.NetworkName)
+			}
+		}
+	}
+
+	d.SetId(vapp.VApp.HREF)
+	d.Set("description", vapp.VApp.Description)
+	d.Set("status", types.VAppStatuses[vapp.VApp.Status])
+	d.Set("deployed", vapp.VApp.Deployed)
+	d.Set("href", vapp.VApp.HREF)
+	d.Set("networks", networks)
+	d.Set("vm", flattenVAppVMs(vapp.VApp))
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdVAppTemplate() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdVAppTemplateRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"catalog_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"storage_profile": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vm_names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceVcdVAppTemplateRead(d *schema.ResourceData, meta interface{}) error {
+	catalogItem, err := findCatalogItem(d, meta)
+	if err != nil {
+		return err
+	}
+
+	vappTemplate, err := catalogItem.GetVAppTemplate()
+	if err != nil {
+		return fmt.Errorf("Error finding VAppTemplate: %#v", err)
+	}
+
+	vmNames := make([]string, 0)
+	if vappTemplate.VAppTemplate.Children != nil {
+		for _, vm := range vappTemplate.VAppTemplate.Children.VM {
+			vmNames = append(vmNames, vm.Name)
+		}
+	}
+
+	d.SetId(vappTemplate.VAppTemplate.HREF)
+	d.Set("description", vappTemplate.VAppTemplate.Description)
+	d.Set("created", vappTemplate.VAppTemplate.DateCreated)
+	d.Set("storage_profile", vappTemplate.VAppTemplate.DefaultStorageProfile)
+	d.Set("href", vappTemplate.VAppTemplate.HREF)
+	d.Set("vm_names", vmNames)
+
+	return nil
+}
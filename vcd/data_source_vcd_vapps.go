@@ -0,0 +1,55 @@
+package vcd
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdVApps() *schema.Resource {
+	q := &queryDataSource{
+		queryType: "vApp",
+		attribute: "vapps",
+		record: map[string]schema.ValueType{
+			"name":                 schema.TypeString,
+			"href":                 schema.TypeString,
+			"status":               schema.TypeString,
+			"deployed":             schema.TypeBool,
+			"enabled":              schema.TypeBool,
+			"busy":                 schema.TypeBool,
+			"vdc_name":             schema.TypeString,
+			"vdc_href":             schema.TypeString,
+			"owner_name":           schema.TypeString,
+			"number_of_vms":        schema.TypeInt,
+			"number_of_cpus":       schema.TypeInt,
+			"memory_allocation_mb": schema.TypeInt,
+			"storage_kb":           schema.TypeInt,
+			"creation_date":        schema.TypeString,
+		},
+		flatten: flattenVAppRecords,
+	}
+
+	return q.Resource()
+}
+
+func flattenVAppRecords(results *types.QueryResultRecordsType) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(results.VAppRecord))
+	for _, record := range results.VAppRecord {
+		records = append(records, map[string]interface{}{
+			"name":                 record.Name,
+			"href":                 record.HREF,
+			"status":               record.Status,
+			"deployed":             record.Deployed,
+			"enabled":              record.Enabled,
+			"busy":                 record.Busy,
+			"vdc_name":             record.VdcName,
+			"vdc_href":             record.VdcHREF,
+			"owner_name":           record.OwnerName,
+			"number_of_vms":        record.NumberOfVMs,
+			"number_of_cpus":       record.NumberOfCPUs,
+			"memory_allocation_mb": record.MemoryAllocationMB,
+			"storage_kb":           record.StorageKB,
+			"creation_date":        record.CreationDate,
+		})
+	}
+	return records
+}
@@ -0,0 +1,99 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdVdc() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdVdcRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"allocation_model": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_enabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"network_quota": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"nic_quota": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"vm_quota": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"storage_profiles": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"networks": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceVcdVdcRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	orgName, _ := d.Get("org").(string)
+	vdc, err := vcdClient.findVdc(orgName, d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	storageProfiles := make([]string, 0)
+	for _, sps := range vdc.Vdc.VdcStorageProfiles {
+		storageProfiles = append(storageProfiles, referenceNames(sps.VdcStorageProfile)...)
+	}
+
+	networks := make([]string, 0)
+	for _, ans := range vdc.Vdc.AvailableNetworks {
+		networks = append(networks, referenceNames(ans.Network)...)
+	}
+
+	d.SetId(vdc.Vdc.HREF)
+	d.Set("name", vdc.Vdc.Name)
+	d.Set("description", vdc.Vdc.Description)
+	d.Set("allocation_model", vdc.Vdc.AllocationModel)
+	d.Set("is_enabled", vdc.Vdc.IsEnabled)
+	d.Set("network_quota", vdc.Vdc.NetworkQuota)
+	d.Set("nic_quota", vdc.Vdc.NicQuota)
+	d.Set("vm_quota", vdc.Vdc.VMQuota)
+	d.Set("href", vdc.Vdc.HREF)
+	d.Set("storage_profiles", storageProfiles)
+	d.Set("networks", networks)
+
+	return nil
+}
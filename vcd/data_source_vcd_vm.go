@@ -0,0 +1,149 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdVM() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdVMRead,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			"vapp_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"cpus": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"memory": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"storage_profile": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"nested_hypervisor_enabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"computer_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vapp_href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"network": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ip": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ip_allocation_mode": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"is_primary": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"is_connected": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"adapter_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdVMRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	vapp, err := vdc.FindVAppByName(d.Get("vapp_name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding VApp: %#v", err)
+	}
+
+	vm, err := vdc.FindVMByName(vapp, d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding VM: %#v", err)
+	}
+
+	cpuCount, err := vm.GetCPUCount()
+	if err != nil {
+		return err
+	}
+
+	memoryCount, err := vm.GetMemoryCount()
+	if err != nil {
+		return err
+	}
+
+	readNetworks := make([]map[string]interface{}, 0)
+	if section := vm.VM.NetworkConnectionSection; section != nil {
+		for _, networkConnection := range section.NetworkConnection {
+			readNetworks = append(readNetworks, readVMNetwork(networkConnection, section.PrimaryNetworkConnectionIndex))
+		}
+	}
+
+	d.SetId(vm.VM.HREF)
+	d.Set("description", vm.VM.Description)
+	d.Set("status", types.VAppStatuses[vm.VM.Status])
+	d.Set("cpus", cpuCount)
+	d.Set("memory", memoryCount)
+	d.Set("nested_hypervisor_enabled", vm.VM.NestedHypervisorEnabled)
+	d.Set("href", vm.VM.HREF)
+	d.Set("vapp_href", vapp.VApp.HREF)
+	d.Set("network", readNetworks)
+	if vm.VM.StorageProfile != nil {
+		d.Set("storage_profile", vm.VM.StorageProfile.Name)
+	}
+	if vm.VM.GuestCustomizationSection != nil {
+		d.Set("computer_name", vm.VM.GuestCustomizationSection.ComputerName)
+	}
+
+	return nil
+}
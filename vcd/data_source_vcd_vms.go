@@ -0,0 +1,58 @@
+package vcd
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func dataSourceVcdVMs() *schema.Resource {
+	q := &queryDataSource{
+		queryType: "vm",
+		attribute: "vms",
+		filter:    "isVAppTemplate==false",
+		record: map[string]schema.ValueType{
+			"name":             schema.TypeString,
+			"href":             schema.TypeString,
+			"status":           schema.TypeString,
+			"deployed":         schema.TypeBool,
+			"busy":             schema.TypeBool,
+			"vapp_name":        schema.TypeString,
+			"vapp_href":        schema.TypeString,
+			"vdc_href":         schema.TypeString,
+			"guest_os":         schema.TypeString,
+			"memory":           schema.TypeInt,
+			"cpus":             schema.TypeInt,
+			"storage_profile":  schema.TypeString,
+			"network_name":     schema.TypeString,
+			"vm_tools_version": schema.TypeString,
+			"hardware_version": schema.TypeInt,
+		},
+		flatten: flattenVMRecords,
+	}
+
+	return q.Resource()
+}
+
+func flattenVMRecords(results *types.QueryResultRecordsType) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(results.VMRecord))
+	for _, record := range results.VMRecord {
+		records = append(records, map[string]interface{}{
+			"name":             record.Name,
+			"href":             record.HREF,
+			"status":           record.Status,
+			"deployed":         record.Deployed,
+			"busy":             record.Busy,
+			"vapp_name":        record.VAppParentName,
+			"vapp_href":        record.VAppParentHREF,
+			"vdc_href":         record.VdcHREF,
+			"guest_os":         record.GuestOS,
+			"memory":           record.MemoryMB,
+			"cpus":             record.Cpus,
+			"storage_profile":  record.StorageProfileName,
+			"network_name":     record.NetworkName,
+			"vm_tools_version": record.VmToolsVersion,
+			"hardware_version": record.HardwareVersion,
+		})
+	}
+	return records
+}
@@ -0,0 +1,241 @@
+package vcd
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	authTypePassword = "password"
+	authTypeToken    = "token"
+	authTypeAPIToken = "api_token"
+
+	headerAuthorization = "x-vcloud-authorization"
+	headerAccessToken   = "X-VMWARE-VCLOUD-ACCESS-TOKEN"
+)
+
+// authTransport authenticates the requests of a vCD client and logs in again
+// when the session expires during a run. The vendored client only knows how
+// to open a session with a user and a password, the transport turns its
+// login request into a session request authenticated by a token for the
+// other authentication types.
+type authTransport struct {
+	base http.RoundTripper
+
+	authType string
+	// The session token of the token authentication type
+	token string
+	// The refresh token of the api_token authentication type
+	apiToken string
+	org      string
+
+	// login opens a new session through the vendored client, it is nil until
+	// the client is set up
+	login func() error
+
+	// Serializes the logins
+	loginMutex sync.Mutex
+
+	sessionMutex sync.RWMutex
+	// The token of the current session, sent as a bearer token when bearer is
+	// set
+	session string
+	bearer  bool
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/sessions") {
+		return t.openSession(req)
+	}
+
+	session := t.currentSession()
+	resp, err := t.base.RoundTrip(t.authorize(req, session))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || t.login == nil {
+		return resp, err
+	}
+
+	// A request with a body which cannot be read again is not retried
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	err = t.relogin(session)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot replay request: %s %s", req.Method, req.URL)
+		}
+	}
+
+	return t.base.RoundTrip(t.authorize(retry, t.currentSession()))
+}
+
+func (t *authTransport) currentSession() string {
+	t.sessionMutex.RLock()
+	defer t.sessionMutex.RUnlock()
+	return t.session
+}
+
+func (t *authTransport) setSession(session string, bearer bool) {
+	t.sessionMutex.Lock()
+	defer t.sessionMutex.Unlock()
+	t.session = session
+	t.bearer = bearer
+}
+
+// authorize returns a copy of the request carrying the given session, the
+// token the vendored client has set may be outdated
+func (t *authTransport) authorize(req *http.Request, session string) *http.Request {
+	if session == "" {
+		return req
+	}
+
+	t.sessionMutex.RLock()
+	bearer := t.bearer
+	t.sessionMutex.RUnlock()
+
+	authorized := req.Clone(req.Context())
+	if bearer {
+		authorized.Header.Del(headerAuthorization)
+		authorized.Header.Set("Authorization", "Bearer "+session)
+	} else {
+		authorized.Header.Set(headerAuthorization, session)
+	}
+	return authorized
+}
+
+// relogin opens a new session unless another request already replaced the
+// expired session
+func (t *authTransport) relogin(expired string) error {
+	t.loginMutex.Lock()
+	defer t.loginMutex.Unlock()
+
+	if t.currentSession() != expired {
+		return nil
+	}
+
+	if t.authType == authTypeToken {
+		return errors.New("the session token has expired, a new token is required")
+	}
+
+	log.Printf("[INFO] The vCD session has expired, logging in again")
+	err := t.login()
+	if err != nil {
+		return errors.Wrapf(err, "cannot log in again")
+	}
+	return nil
+}
+
+// openSession handles the login request of the vendored client
+func (t *authTransport) openSession(req *http.Request) (*http.Response, error) {
+	switch t.authType {
+	case authTypeToken:
+		return t.getSession(req, func(sessionReq *http.Request) {
+			sessionReq.Header.Set(headerAuthorization, t.token)
+		}, t.token, false)
+
+	case authTypeAPIToken:
+		accessToken, err := t.refreshAccessToken(req)
+		if err != nil {
+			return nil, err
+		}
+		return t.getSession(req, func(sessionReq *http.Request) {
+			sessionReq.Header.Set("Authorization", "Bearer "+accessToken)
+		}, accessToken, true)
+
+	default:
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			t.setSession(resp.Header.Get(headerAuthorization), false)
+		}
+		return resp, err
+	}
+}
+
+// getSession reads the current session instead of opening one, its response
+// holds the same links as the response of a login
+func (t *authTransport) getSession(req *http.Request, authenticate func(*http.Request), token string, bearer bool) (*http.Response, error) {
+	sessionURL := *req.URL
+	sessionURL.Path = strings.TrimSuffix(sessionURL.Path, "s")
+
+	sessionReq, err := http.NewRequest(http.MethodGet, sessionURL.String(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot create session request: %s", sessionURL.String())
+	}
+	sessionReq = sessionReq.WithContext(req.Context())
+	sessionReq.Header.Set("Accept", req.Header.Get("Accept"))
+	authenticate(sessionReq)
+
+	resp, err := t.base.RoundTrip(sessionReq)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	if accessToken := resp.Header.Get(headerAccessToken); bearer && accessToken != "" {
+		token = accessToken
+	}
+	t.setSession(token, bearer)
+
+	// The vendored client keeps the token of the response
+	resp.Header.Set(headerAuthorization, token)
+	return resp, nil
+}
+
+// refreshAccessToken exchanges the API token for an access token
+func (t *authTransport) refreshAccessToken(req *http.Request) (string, error) {
+	tokenURL := url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: "/oauth/tenant/" + t.org + "/token"}
+	if strings.EqualFold(t.org, "system") {
+		tokenURL.Path = "/oauth/provider/token"
+	}
+
+	form := url.Values{
+		"grant_type":    []string{"refresh_token"},
+		"refresh_token": []string{t.apiToken},
+	}
+	tokenReq, err := http.NewRequest(http.MethodPost, tokenURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot create token request: %s", tokenURL.String())
+	}
+	tokenReq = tokenReq.WithContext(req.Context())
+	tokenReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenReq.Header.Set("Accept", "application/json")
+
+	resp, err := t.base.RoundTrip(tokenReq)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot execute request: POST %s", tokenURL.String())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("cannot exchange the API token: POST %s: %s", tokenURL.String(), resp.Status)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot decode response: POST %s", tokenURL.String())
+	}
+	if token.AccessToken == "" {
+		return "", errors.Errorf("no access token in response: POST %s", tokenURL.String())
+	}
+
+	return token.AccessToken, nil
+}
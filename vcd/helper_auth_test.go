@@ -0,0 +1,143 @@
+package vcd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testAuthServer accepts the requests carrying the current session and
+// counts the logins
+type testAuthServer struct {
+	session string
+	logins  int
+}
+
+func (s *testAuthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/sessions":
+		s.logins++
+		s.session = fmt.Sprintf("session-%d", s.logins)
+		w.Header().Set(headerAuthorization, s.session)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/session":
+		if r.Header.Get(headerAuthorization) != "token" && r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.session = "token"
+	case r.Method == http.MethodPost && r.URL.Path == "/oauth/tenant/org/token":
+		r.ParseForm()
+		if r.Form.Get("refresh_token") != "api-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "access"}`)
+	default:
+		if r.Header.Get(headerAuthorization) != s.session && r.Header.Get("Authorization") != "Bearer "+s.session {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}
+}
+
+func TestAuthTransportRelogin(t *testing.T) {
+	server := &testAuthServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	transport := &authTransport{base: http.DefaultTransport, authType: authTypePassword}
+	client := &http.Client{Transport: transport}
+	transport.login = func() error {
+		resp, err := client.Post(ts.URL+"/api/sessions", "", nil)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	if err := transport.login(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The session expires on the server
+	server.session = "expired"
+
+	resp, err := client.Post(ts.URL+"/api/vApp", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+		t.Errorf("expected the request to be replayed, got %s %q", resp.Status, body)
+	}
+	if server.logins != 2 || transport.currentSession() != "session-2" {
+		t.Errorf("expected a single login again, got %d logins and session %s", server.logins, transport.currentSession())
+	}
+}
+
+func TestAuthTransportToken(t *testing.T) {
+	server := &testAuthServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	cases := []struct {
+		transport *authTransport
+		bearer    string
+	}{
+		{&authTransport{base: http.DefaultTransport, authType: authTypeToken, token: "token"}, ""},
+		{&authTransport{base: http.DefaultTransport, authType: authTypeAPIToken, apiToken: "api-token", org: "org"}, "access"},
+	}
+
+	for _, c := range cases {
+		client := &http.Client{Transport: c.transport}
+
+		resp, err := client.Post(ts.URL+"/api/sessions", "", nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.transport.authType, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(headerAuthorization) == "" {
+			t.Errorf("%s: expected the session to be read, got %s", c.transport.authType, resp.Status)
+		}
+		if server.logins != 0 {
+			t.Errorf("%s: expected no login with a password", c.transport.authType)
+		}
+		if c.bearer != "" && (!c.transport.bearer || c.transport.currentSession() != c.bearer) {
+			t.Errorf("%s: expected the bearer token %s, got %s", c.transport.authType, c.bearer, c.transport.currentSession())
+		}
+	}
+
+	// A session token cannot be renewed
+	transport := &authTransport{base: http.DefaultTransport, authType: authTypeToken, session: "expired", login: func() error { return nil }}
+	if err := transport.relogin("expired"); err == nil {
+		t.Errorf("expected an error for an expired session token")
+	}
+}
+
+func TestConfigValidateAuth(t *testing.T) {
+	cases := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{User: "user", Password: "password"}, true},
+		{Config{AuthType: authTypePassword, User: "user"}, false},
+		{Config{AuthType: authTypeToken, Token: "token"}, true},
+		{Config{AuthType: authTypeToken, User: "user", Password: "password"}, false},
+		{Config{AuthType: authTypeAPIToken, APIToken: "api-token"}, true},
+		{Config{AuthType: "saml"}, false},
+	}
+
+	for _, c := range cases {
+		if err := c.config.validateAuth(); (err == nil) != c.valid {
+			t.Errorf("%#v: expected valid=%t, got %v", c.config, c.valid, err)
+		}
+	}
+}
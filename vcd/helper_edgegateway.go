@@ -0,0 +1,101 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+const mimeEdgeGatewayServiceConfiguration = "application/vnd.vmware.admin.edgeGatewayServiceConfiguration+xml"
+
+// edgeGatewayServices holds the services of an edge gateway the vendored
+// types can only hold a single item of. vCD only changes the services which
+// are part of a configureServices request, the other services are kept.
+type edgeGatewayServices struct {
+	XMLName              xml.Name                  `xml:"EdgeGatewayServiceConfiguration"`
+	Xmlns                string                    `xml:"xmlns,attr,omitempty"`
+	GatewayDhcpService   *types.GatewayDhcpService `xml:"GatewayDhcpService,omitempty"`
+	FirewallService      *types.FirewallService    `xml:"FirewallService,omitempty"`
+	NatService           *types.NatService         `xml:"NatService,omitempty"`
+	IpsecVpnService      *ipsecVpnService          `xml:"GatewayIpsecVpnService,omitempty"`
+	StaticRoutingService *staticRoutingService     `xml:"StaticRoutingService,omitempty"`
+	LoadBalancerService  *loadBalancerService      `xml:"LoadBalancerService,omitempty"`
+}
+
+// edgeGatewayConfiguration is the part of an edge gateway holding its services
+type edgeGatewayConfiguration struct {
+	Configuration struct {
+		EdgeGatewayServiceConfiguration edgeGatewayServices `xml:"EdgeGatewayServiceConfiguration"`
+	} `xml:"Configuration"`
+}
+
+type staticRoutingService struct {
+	IsEnabled   bool           `xml:"IsEnabled"`
+	StaticRoute []*staticRoute `xml:"StaticRoute,omitempty"`
+}
+
+type staticRoute struct {
+	Name             string           `xml:"Name"`
+	Network          string           `xml:"Network"`
+	NextHopIP        string           `xml:"NextHopIp"`
+	Interface        string           `xml:"Interface,omitempty"`
+	GatewayInterface *types.Reference `xml:"GatewayInterface,omitempty"`
+}
+
+// readEdgeGatewayServices reads the services of the edge gateway at href
+func readEdgeGatewayServices(client *govcd.Client, href string) (*edgeGatewayServices, error) {
+	edgeGateway := &edgeGatewayConfiguration{}
+
+	err := getXML(client, href, edgeGateway)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read edge gateway: %s", href)
+	}
+
+	return &edgeGateway.Configuration.EdgeGatewayServiceConfiguration, nil
+}
+
+// configureEdgeGatewayServices sends the services to the edge gateway at
+// href, the services which are not set are left unchanged
+func configureEdgeGatewayServices(client *govcd.Client, href string, services *edgeGatewayServices) (govcd.Task, error) {
+	services.Xmlns = types.XMLNamespaceXMLNS
+
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, http.MethodPost, href+"/action/configureServices", mimeEdgeGatewayServiceConfiguration, services, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
+
+// findGatewayInterface returns the interface of the edge gateway connected
+// to the network with the given name
+func findGatewayInterface(edgeGateway *types.EdgeGateway, networkName string) *types.GatewayInterface {
+	if edgeGateway.Configuration == nil || edgeGateway.Configuration.GatewayInterfaces == nil {
+		return nil
+	}
+
+	for _, gatewayInterface := range edgeGateway.Configuration.GatewayInterfaces.GatewayInterface {
+		if gatewayInterface.Network != nil && gatewayInterface.Network.Name == networkName {
+			return gatewayInterface
+		}
+	}
+	return nil
+}
+
+// findGatewayInterfaceByHREF returns the interface of the edge gateway
+// connected to the network with the given HREF
+func findGatewayInterfaceByHREF(edgeGateway *types.EdgeGateway, networkHREF string) *types.GatewayInterface {
+	if edgeGateway.Configuration == nil || edgeGateway.Configuration.GatewayInterfaces == nil {
+		return nil
+	}
+
+	for _, gatewayInterface := range edgeGateway.Configuration.GatewayInterfaces.GatewayInterface {
+		if gatewayInterface.Network != nil && gatewayInterface.Network.HREF == networkHREF {
+			return gatewayInterface
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func staticRouteNetworks(routes []*staticRoute) []string {
+	networks := make([]string, 0, len(routes))
+	for _, route := range routes {
+		networks = append(networks, route.Network+" via "+route.NextHopIP)
+	}
+	return networks
+}
+
+func TestSetStaticRoute(t *testing.T) {
+	routes := testReadEdgeGatewayServices(t).StaticRoutingService.StaticRoute
+
+	cases := []struct {
+		name     string
+		network  string
+		route    *staticRoute
+		expected []string
+	}{
+		{"add", "10.30.0.0/16", &staticRoute{Network: "10.30.0.0/16", NextHopIP: "192.168.1.3"},
+			[]string{"10.10.0.0/16 via 192.168.1.1", "10.20.0.0/16 via 192.168.1.2", "10.30.0.0/16 via 192.168.1.3"}},
+		{"update", "10.10.0.0/16", &staticRoute{Network: "10.10.0.0/16", NextHopIP: "192.168.1.4"},
+			[]string{"10.10.0.0/16 via 192.168.1.4", "10.20.0.0/16 via 192.168.1.2"}},
+		{"remove", "10.10.0.0/16", nil,
+			[]string{"10.20.0.0/16 via 192.168.1.2"}},
+	}
+
+	for _, c := range cases {
+		updated := staticRouteNetworks(setStaticRoute(routes, c.network, c.route))
+		if !reflect.DeepEqual(updated, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, updated)
+		}
+	}
+}
+
+func TestEdgeGatewayServicesXML(t *testing.T) {
+	services := testReadEdgeGatewayServices(t)
+
+	output, err := xml.Marshal(&edgeGatewayServices{StaticRoutingService: services.StaticRoutingService})
+	if err != nil {
+		t.Fatalf("cannot marshal edge gateway services: %v", err)
+	}
+
+	if strings.Contains(string(output), "FirewallService") {
+		t.Errorf("expected only the static routing service, got %s", output)
+	}
+	if strings.Count(string(output), "<StaticRoute>") != 2 {
+		t.Errorf("expected both static routes to be kept, got %s", output)
+	}
+}
+
+func dhcpPoolRanges(pools []*types.DhcpPoolService) []string {
+	ranges := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		ranges = append(ranges, pool.LowIPAddress+"-"+pool.HighIPAddress)
+	}
+	return ranges
+}
+
+func TestSetDhcpPool(t *testing.T) {
+	edgeGateway := testReadEdgeGatewayDhcp(t)
+	pools := edgeGatewayDhcpService(edgeGateway).Pool
+
+	if pool := findDhcpPool(edgeGateway, "net-a"); pool == nil || pool.LowIPAddress != "10.0.0.100" {
+		t.Errorf("expected the pool of net-a to be found by HREF, got %#v", pool)
+	}
+	if pool := findDhcpPool(edgeGateway, "net-c"); pool != nil {
+		t.Errorf("expected no pool for net-c, got %#v", pool)
+	}
+
+	updated := &types.DhcpPoolService{
+		Network:       &types.Reference{HREF: "https://vcd.example.com/api/admin/network/a", Name: "net-a"},
+		LowIPAddress:  "10.0.0.50",
+		HighIPAddress: "10.0.0.60",
+	}
+
+	cases := []struct {
+		name     string
+		network  string
+		pool     *types.DhcpPoolService
+		expected []string
+	}{
+		{"update", "net-a", updated, []string{"10.0.1.100-10.0.1.200", "10.0.0.50-10.0.0.60"}},
+		{"remove", "net-a", nil, []string{"10.0.1.100-10.0.1.200"}},
+		{"remove by name", "net-b", nil, []string{"10.0.0.100-10.0.0.200"}},
+	}
+
+	for _, c := range cases {
+		ranges := dhcpPoolRanges(setDhcpPool(pools, dhcpPoolMatcher(edgeGateway, c.network), c.pool))
+		if !reflect.DeepEqual(ranges, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, ranges)
+		}
+	}
+}
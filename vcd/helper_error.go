@@ -0,0 +1,186 @@
+package vcd
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// The classes of the vCD errors, the errors of the vendored client and of the
+// provider are matched against them with the is* functions below
+var (
+	errBusyEntity   = errors.New("the entity is busy")
+	errNotFound     = errors.New("the entity is not found")
+	errAccessDenied = errors.New("access is denied")
+	errConflict     = errors.New("the entity conflicts with an existing one")
+	errThrottled    = errors.New("too many requests")
+)
+
+// The vendored finders report a missing entity with a plain error, these
+// match their messages
+var notFoundMessages = []*regexp.Regexp{
+	regexp.MustCompile(`^can't find (VDC Network|VDC Storage_profile|edge gateway with name|vApp|vm): `),
+	regexp.MustCompile(`^cannot find (catalog|catalog item): `),
+	regexp.MustCompile(`^cannot find (Catalog|VDC) endpoint: name=`),
+	regexp.MustCompile(`^disk '.*' was not found$`),
+}
+
+// The finders by HREF flatten the vCD error into their own, this matches its
+// major code
+var flattenedVcdError = regexp.MustCompile(`^error retrieving (VM|VApp|catalog): API Error: (\d+): `)
+
+func isBusyEntity(err error) bool {
+	return classifyError(err) == errBusyEntity
+}
+
+func isNotFound(err error) bool {
+	return classifyError(err) == errNotFound
+}
+
+func isAccessDenied(err error) bool {
+	return classifyError(err) == errAccessDenied
+}
+
+// isGoneByHREF reports whether an entity read by its HREF no longer exists,
+// vCD denies the access to the HREFs of the deleted vApps and VMs instead of
+// reporting them as not found
+func isGoneByHREF(err error) bool {
+	class := classifyError(err)
+	return class == errNotFound || class == errAccessDenied
+}
+
+func isConflict(err error) bool {
+	return classifyError(err) == errConflict
+}
+
+func isThrottled(err error) bool {
+	return classifyError(err) == errThrottled
+}
+
+// isTransientError reports whether an error is caused by the current state of
+// the entity or of the service rather than by the request, the request may
+// succeed when it is sent again. A gateway error is not transient, the request
+// may have been processed. The idempotent requests are already sent again by
+// retryTransport.
+func isTransientError(err error) bool {
+	if isBusyEntity(err) || isThrottled(err) {
+		return true
+	}
+
+	vcdError := findVcdError(err)
+	return vcdError != nil && vcdError.MajorErrorCode == http.StatusServiceUnavailable
+}
+
+// isInternalServerError reports whether vCD failed with an internal error,
+// which is the error of some concurrent changes of a vApp
+func isInternalServerError(err error) bool {
+	vcdError := findVcdError(err)
+	return vcdError != nil && vcdError.MajorErrorCode == http.StatusInternalServerError &&
+		vcdError.MinorErrorCode == "INTERNAL_SERVER_ERROR"
+}
+
+// isUnavailableStatus reports whether an HTTP status means that the service
+// is unavailable for a while
+func isUnavailableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// classifyError returns the class of the error, one of the err* sentinels, or
+// nil when the error is of none of them
+func classifyError(err error) error {
+	var class error
+	walkErrors(err, func(err error) bool {
+		for _, sentinel := range []error{errBusyEntity, errNotFound, errAccessDenied, errConflict, errThrottled} {
+			if err == sentinel {
+				class = sentinel
+				return true
+			}
+		}
+
+		if vcdError, ok := err.(*types.Error); ok {
+			class = classifyVcdError(vcdError)
+			return true
+		}
+
+		for _, message := range notFoundMessages {
+			if message.MatchString(err.Error()) {
+				class = errNotFound
+				return true
+			}
+		}
+
+		if match := flattenedVcdError.FindStringSubmatch(err.Error()); match != nil {
+			majorErrorCode, _ := strconv.Atoi(match[2])
+			class = classifyVcdError(&types.Error{MajorErrorCode: majorErrorCode})
+			return true
+		}
+		return false
+	})
+	return class
+}
+
+func classifyVcdError(err *types.Error) error {
+	switch {
+	case err.MajorErrorCode == http.StatusBadRequest && err.MinorErrorCode == "BUSY_ENTITY":
+		return errBusyEntity
+	case err.MajorErrorCode == http.StatusNotFound:
+		return errNotFound
+	case err.MajorErrorCode == http.StatusUnauthorized || err.MajorErrorCode == http.StatusForbidden:
+		return errAccessDenied
+	case err.MajorErrorCode == http.StatusConflict || err.MinorErrorCode == "DUPLICATE_NAME":
+		return errConflict
+	case err.MajorErrorCode == http.StatusTooManyRequests:
+		return errThrottled
+	}
+	return nil
+}
+
+// findVcdError returns the vCD error the error wraps, if any
+func findVcdError(err error) *types.Error {
+	var vcdError *types.Error
+	walkErrors(err, func(err error) bool {
+		vcdError, _ = err.(*types.Error)
+		return vcdError != nil
+	})
+	return vcdError
+}
+
+// walkErrors calls f on the error and on the errors it wraps, until f returns
+// true. Both the errors wrapped by github.com/pkg/errors and the standard ones
+// are unwrapped.
+func walkErrors(err error, f func(error) bool) {
+	for err != nil {
+		if f(err) {
+			return
+		}
+
+		switch wrapper := err.(type) {
+		case interface{ Cause() error }:
+			err = wrapper.Cause()
+		case interface{ Unwrap() error }:
+			err = wrapper.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+// retryError is the result of a call retried by retryCall, the call is retried
+// when its error is transient
+func retryError(err error) *resource.RetryError {
+	if err == nil {
+		return nil
+	}
+	if isTransientError(err) {
+		return resource.RetryableError(err)
+	}
+	return resource.NonRetryableError(err)
+}
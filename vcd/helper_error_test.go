@@ -0,0 +1,89 @@
+package vcd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err       error
+		class     error
+		transient bool
+	}{
+		{err: nil},
+		{err: fmt.Errorf("connection refused")},
+		{err: &types.Error{MajorErrorCode: 400, MinorErrorCode: "BUSY_ENTITY"}, class: errBusyEntity, transient: true},
+		{err: &types.Error{MajorErrorCode: 400, MinorErrorCode: "BAD_REQUEST"}},
+		{err: &types.Error{MajorErrorCode: 404, MinorErrorCode: "RESOURCE_NOT_FOUND"}, class: errNotFound},
+		{err: &types.Error{MajorErrorCode: 403, MinorErrorCode: "ACCESS_TO_RESOURCE_IS_FORBIDDEN"}, class: errAccessDenied},
+		{err: &types.Error{MajorErrorCode: 400, MinorErrorCode: "DUPLICATE_NAME"}, class: errConflict},
+		{err: &types.Error{MajorErrorCode: 429, MinorErrorCode: "TOO_MANY_REQUESTS"}, class: errThrottled, transient: true},
+		{err: &types.Error{MajorErrorCode: 502, MinorErrorCode: "BAD_GATEWAY"}},
+		{err: &types.Error{MajorErrorCode: 503, MinorErrorCode: "SERVICE_UNAVAILABLE"}, transient: true},
+		{err: fmt.Errorf("unhandled API response, please report this issue, status code: 502 Bad Gateway")},
+		{err: &types.Error{MajorErrorCode: 500, MinorErrorCode: "INTERNAL_SERVER_ERROR"}},
+		{err: fmt.Errorf("can't find vApp: web"), class: errNotFound},
+		{err: fmt.Errorf("disk 'data' was not found"), class: errNotFound},
+		{err: fmt.Errorf("error retrieving VM: API Error: 404: The VM does not exist"), class: errNotFound},
+		{err: fmt.Errorf("error retrieving VApp: API Error: 403: Either you need some or all of the following rights"), class: errAccessDenied},
+		{err: fmt.Errorf("error retrieving VApp: API Error: 500: Internal Server Error")},
+		{err: errors.Wrap(fmt.Errorf("can't find edge gateway with name: gw"), "Unable to find edge gateway"), class: errNotFound},
+		{err: errors.Wrap(&types.Error{MajorErrorCode: 400, MinorErrorCode: "BUSY_ENTITY"}, "cannot delete disk"), class: errBusyEntity, transient: true},
+		{
+			err:       &taskError{task: &types.Task{Name: "task", Status: "error", Error: &types.Error{MajorErrorCode: 400, MinorErrorCode: "BUSY_ENTITY"}}},
+			class:     errBusyEntity,
+			transient: true,
+		},
+		{err: &taskError{task: &types.Task{Name: "task", Status: "aborted"}}},
+		{err: errors.Wrap(errThrottled, "cannot read vApp"), class: errThrottled, transient: true},
+	}
+
+	for _, c := range cases {
+		if class := classifyError(c.err); class != c.class {
+			t.Errorf("%v: expected class %v, got %v", c.err, c.class, class)
+		}
+		if transient := isTransientError(c.err); transient != c.transient {
+			t.Errorf("%v: expected transient=%t", c.err, c.transient)
+		}
+		if retry := retryError(c.err); (retry == nil) != (c.err == nil) || (retry != nil && retry.Retryable != c.transient) {
+			t.Errorf("%v: expected a retry error with retryable=%t, got %v", c.err, c.transient, retry)
+		}
+	}
+}
+
+func TestIsGoneByHREF(t *testing.T) {
+	cases := []struct {
+		status int
+		gone   bool
+	}{
+		{status: http.StatusForbidden, gone: true},
+		{status: http.StatusNotFound, gone: true},
+		{status: http.StatusInternalServerError, gone: false},
+	}
+
+	for _, c := range cases {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			fmt.Fprintf(w, `<Error xmlns="http://www.vmware.com/vcloud/v1.5" message="%s" majorErrorCode="%d" minorErrorCode="%s"/>`,
+				http.StatusText(c.status), c.status, http.StatusText(c.status))
+		}))
+
+		vdc := govcd.NewVdc(&govcd.Client{})
+		_, vappErr := vdc.GetVAppByHREF(ts.URL + "/api/vApp/vapp-1")
+		_, vmErr := vdc.GetVMByHREF(ts.URL + "/api/vApp/vm-1")
+		ts.Close()
+
+		for _, err := range []error{vappErr, vmErr} {
+			if err == nil || isGoneByHREF(err) != c.gone {
+				t.Errorf("%d: expected gone=%t, got %v", c.status, c.gone, err)
+			}
+		}
+	}
+}
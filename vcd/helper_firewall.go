@@ -0,0 +1,268 @@
+package vcd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// firewallRuleSchema returns the schema of the fields of a firewall rule
+func firewallRuleSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"description": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"policy": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"protocol": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"destination_port": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"destination_ip": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"source_port": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"source_ip": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+
+		"is_enabled": &schema.Schema{
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  true,
+		},
+
+		"enable_logging": &schema.Schema{
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+
+		"match_on_translate": &schema.Schema{
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+	}
+}
+
+// expandFirewallRules returns the configured rules, the rules keep the ID
+// they have in the state
+func expandFirewallRules(d *schema.ResourceData) []*types.FirewallRule {
+	ruleList := d.Get("rule").([]interface{})
+	firewallRules := make([]*types.FirewallRule, 0, len(ruleList))
+	for _, rule := range ruleList {
+		data := rule.(map[string]interface{})
+		firewallRule := expandFirewallRule(data)
+		firewallRule.ID = data["id"].(string)
+		firewallRules = append(firewallRules, firewallRule)
+	}
+
+	return firewallRules
+}
+
+// expandFirewallRule returns the rule with the fields of firewallRuleSchema
+func expandFirewallRule(data map[string]interface{}) *types.FirewallRule {
+	var protocol *types.FirewallRuleProtocols
+	switch data["protocol"].(string) {
+	case "tcp":
+		protocol = &types.FirewallRuleProtocols{
+			TCP: true,
+		}
+	case "udp":
+		protocol = &types.FirewallRuleProtocols{
+			UDP: true,
+		}
+	case "icmp":
+		protocol = &types.FirewallRuleProtocols{
+			ICMP: true,
+		}
+	default:
+		protocol = &types.FirewallRuleProtocols{
+			Any: true,
+		}
+	}
+
+	return &types.FirewallRule{
+		IsEnabled:            data["is_enabled"].(bool),
+		MatchOnTranslate:     data["match_on_translate"].(bool),
+		Description:          data["description"].(string),
+		Policy:               data["policy"].(string),
+		Protocols:            protocol,
+		Port:                 getNumericPort(data["destination_port"]),
+		DestinationPortRange: data["destination_port"].(string),
+		DestinationIP:        data["destination_ip"].(string),
+		SourcePort:           getNumericPort(data["source_port"]),
+		SourcePortRange:      data["source_port"].(string),
+		SourceIP:             data["source_ip"].(string),
+		EnableLogging:        data["enable_logging"].(bool),
+	}
+}
+
+func flattenFirewallRule(rule *types.FirewallRule) map[string]interface{} {
+	protocol := "any"
+	if rule.Protocols != nil {
+		protocol = getProtocol(*rule.Protocols)
+	}
+
+	destinationPort := rule.DestinationPortRange
+	if destinationPort == "" {
+		destinationPort = getPortString(rule.Port)
+	}
+
+	sourcePort := rule.SourcePortRange
+	if sourcePort == "" {
+		sourcePort = getPortString(rule.SourcePort)
+	}
+
+	return map[string]interface{}{
+		"id":                 rule.ID,
+		"description":        rule.Description,
+		"policy":             rule.Policy,
+		"protocol":           protocol,
+		"destination_port":   strings.ToLower(destinationPort),
+		"destination_ip":     strings.ToLower(rule.DestinationIP),
+		"source_port":        strings.ToLower(sourcePort),
+		"source_ip":          strings.ToLower(rule.SourceIP),
+		"is_enabled":         rule.IsEnabled,
+		"enable_logging":     rule.EnableLogging,
+		"match_on_translate": rule.MatchOnTranslate,
+	}
+}
+
+// replaceFirewallRules replaces the owned rules with the given rules, the
+// given rules take the place of the first owned rule or are added at the end.
+// The position of the given rules in the result is returned.
+func replaceFirewallRules(current []*types.FirewallRule, owned []string, rules []*types.FirewallRule) ([]*types.FirewallRule, int) {
+	kept := make([]*types.FirewallRule, 0, len(current))
+	position := -1
+	for _, rule := range current {
+		if isStringMember(owned, rule.ID) {
+			if position == -1 {
+				position = len(kept)
+			}
+			continue
+		}
+		kept = append(kept, rule)
+	}
+	if position == -1 {
+		position = len(kept)
+	}
+
+	updated := make([]*types.FirewallRule, 0, len(kept)+len(rules))
+	updated = append(updated, kept[:position]...)
+	updated = append(updated, rules...)
+	updated = append(updated, kept[position:]...)
+
+	return updated, position
+}
+
+// firewallRuleIDs returns the ids of the rules of the state
+func firewallRuleIDs(ruleList []interface{}) []string {
+	ids := make([]string, 0, len(ruleList))
+	for _, rule := range ruleList {
+		if id := rule.(map[string]interface{})["id"].(string); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// flattenOwnedFirewallRules returns the owned rules in the order of the edge
+// gateway
+func flattenOwnedFirewallRules(rules []*types.FirewallRule, owned []string) []interface{} {
+	ruleList := make([]interface{}, 0, len(owned))
+	for _, rule := range rules {
+		if isStringMember(owned, rule.ID) {
+			ruleList = append(ruleList, flattenFirewallRule(rule))
+		}
+	}
+	return ruleList
+}
+
+func unownedFirewallRules(rules []*types.FirewallRule, owned []string) []*types.FirewallRule {
+	unowned := make([]*types.FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		if !isStringMember(owned, rule.ID) {
+			unowned = append(unowned, rule)
+		}
+	}
+	return unowned
+}
+
+// placeFirewallRule places the rule before or after the rule with the given
+// id. Without any, an existing rule with the same id keeps its place and a new
+// rule is added at the end. The position of the rule in the result is
+// returned.
+func placeFirewallRule(current []*types.FirewallRule, rule *types.FirewallRule, before, after string) ([]*types.FirewallRule, int, error) {
+	others := make([]*types.FirewallRule, 0, len(current)+1)
+	position := -1
+	for _, existing := range current {
+		if rule.ID != "" && existing.ID == rule.ID {
+			position = len(others)
+			continue
+		}
+		others = append(others, existing)
+	}
+
+	if reference := before + after; reference != "" {
+		position = -1
+		for i, existing := range others {
+			if existing.ID == reference {
+				position = i
+				break
+			}
+		}
+		if position == -1 {
+			return nil, 0, fmt.Errorf("Unable to find firewall rule %s", reference)
+		}
+		if after != "" {
+			position++
+		}
+	}
+	if position == -1 {
+		position = len(others)
+	}
+
+	updated := make([]*types.FirewallRule, 0, len(others)+1)
+	updated = append(updated, others[:position]...)
+	updated = append(updated, rule)
+	updated = append(updated, others[position:]...)
+
+	return updated, position, nil
+}
+
+func findFirewallRule(rules []*types.FirewallRule, id string) *types.FirewallRule {
+	for _, rule := range rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// suppressCaseDifferences hides the diff on the ports and the IPs of a rule
+// which vCD only reports in lower case
+func suppressCaseDifferences(k, old, new string, d *schema.ResourceData) bool {
+	return strings.EqualFold(old, new)
+}
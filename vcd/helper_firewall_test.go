@@ -0,0 +1,162 @@
+package vcd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func testFirewallRules(ids ...string) []*types.FirewallRule {
+	rules := make([]*types.FirewallRule, 0, len(ids))
+	for _, id := range ids {
+		rules = append(rules, &types.FirewallRule{ID: id, Description: "rule-" + id})
+	}
+	return rules
+}
+
+func firewallRuleDescriptions(rules []*types.FirewallRule) []string {
+	descriptions := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		descriptions = append(descriptions, rule.Description)
+	}
+	return descriptions
+}
+
+func TestReplaceFirewallRules(t *testing.T) {
+	current := testFirewallRules("1", "2", "3", "4")
+	rules := []*types.FirewallRule{{Description: "new-a"}, {Description: "new-b"}}
+
+	cases := []struct {
+		name             string
+		owned            []string
+		rules            []*types.FirewallRule
+		expected         []string
+		expectedPosition int
+	}{
+		{"create", nil, rules, []string{"rule-1", "rule-2", "rule-3", "rule-4", "new-a", "new-b"}, 4},
+		{"update", []string{"2", "3"}, rules, []string{"rule-1", "new-a", "new-b", "rule-4"}, 1},
+		{"update after out of band removal", []string{"5", "3"}, rules, []string{"rule-1", "rule-2", "new-a", "new-b", "rule-4"}, 2},
+		{"delete", []string{"1", "4"}, nil, []string{"rule-2", "rule-3"}, 0},
+	}
+
+	for _, c := range cases {
+		updated, position := replaceFirewallRules(current, c.owned, c.rules)
+		if descriptions := firewallRuleDescriptions(updated); !reflect.DeepEqual(descriptions, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, descriptions)
+		}
+		if position != c.expectedPosition {
+			t.Errorf("%s: expected position %d, got %d", c.name, c.expectedPosition, position)
+		}
+	}
+}
+
+func TestFlattenOwnedFirewallRules(t *testing.T) {
+	current := testFirewallRules("1", "2", "3")
+	current[2].Protocols = &types.FirewallRuleProtocols{TCP: true}
+	current[2].DestinationPortRange = "443"
+	current[2].Port = 443
+	current[2].SourcePort = -1
+	current[2].IsEnabled = true
+
+	ruleList := flattenOwnedFirewallRules(current, []string{"3", "1", "7"})
+	if len(ruleList) != 2 {
+		t.Fatalf("expected the 2 remaining owned rules, got %#v", ruleList)
+	}
+
+	if id := ruleList[0].(map[string]interface{})["id"]; id != "1" {
+		t.Errorf("expected the rules in the order of the edge gateway, got %v first", id)
+	}
+
+	expected := map[string]interface{}{
+		"id":                 "3",
+		"description":        "rule-3",
+		"policy":             "",
+		"protocol":           "tcp",
+		"destination_port":   "443",
+		"destination_ip":     "",
+		"source_port":        "any",
+		"source_ip":          "",
+		"is_enabled":         true,
+		"enable_logging":     false,
+		"match_on_translate": false,
+	}
+	if !reflect.DeepEqual(ruleList[1], expected) {
+		t.Errorf("expected %#v, got %#v", expected, ruleList[1])
+	}
+}
+
+func TestPlaceFirewallRule(t *testing.T) {
+	current := testFirewallRules("1", "2", "3")
+
+	cases := []struct {
+		name             string
+		rule             *types.FirewallRule
+		before           string
+		after            string
+		expected         []string
+		expectedPosition int
+	}{
+		{"add", &types.FirewallRule{Description: "new"}, "", "", []string{"rule-1", "rule-2", "rule-3", "new"}, 3},
+		{"add before", &types.FirewallRule{Description: "new"}, "1", "", []string{"new", "rule-1", "rule-2", "rule-3"}, 0},
+		{"add after", &types.FirewallRule{Description: "new"}, "", "2", []string{"rule-1", "rule-2", "new", "rule-3"}, 2},
+		{"update in place", &types.FirewallRule{ID: "2", Description: "changed"}, "", "", []string{"rule-1", "changed", "rule-3"}, 1},
+		{"move after", &types.FirewallRule{ID: "1", Description: "moved"}, "", "3", []string{"rule-2", "rule-3", "moved"}, 2},
+	}
+
+	for _, c := range cases {
+		updated, position, err := placeFirewallRule(current, c.rule, c.before, c.after)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if descriptions := firewallRuleDescriptions(updated); !reflect.DeepEqual(descriptions, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, descriptions)
+		}
+		if position != c.expectedPosition {
+			t.Errorf("%s: expected position %d, got %d", c.name, c.expectedPosition, position)
+		}
+	}
+
+	if _, _, err := placeFirewallRule(current, &types.FirewallRule{}, "9", ""); err == nil {
+		t.Errorf("expected an error for an unknown reference rule")
+	}
+}
+
+func TestFirewallRuleCaseDifferences(t *testing.T) {
+	rule := flattenFirewallRule(&types.FirewallRule{
+		Description:   "ssh",
+		Policy:        "allow",
+		Protocols:     &types.FirewallRuleProtocols{TCP: true},
+		Port:          22,
+		DestinationIP: "2001:DB8::10",
+		SourcePort:    -1,
+		SourceIP:      "Any",
+	})
+
+	state := map[string]interface{}{"edge_gateway": "edge"}
+	config := map[string]interface{}{"edge_gateway": "edge"}
+	for key, value := range rule {
+		if key != "id" {
+			state[key] = value
+			config[key] = value
+		}
+	}
+	config["destination_ip"] = "2001:DB8::10"
+	config["source_ip"] = "Any"
+	config["source_port"] = "Any"
+
+	s := resourceVcdFirewallRule().Schema
+	current := schema.TestResourceDataRaw(t, s, state)
+	current.SetId("1")
+
+	diff, err := schema.InternalMap(s).Diff(current.State(), terraform.NewResourceConfigRaw(config), nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && len(diff.Attributes) > 0 {
+		t.Errorf("expected no diff, got %v", diff.Attributes)
+	}
+}
@@ -0,0 +1,55 @@
+package vcd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// isHREF reports whether an import ID is a full vCloud API reference
+// rather than a name
+func isHREF(id string) bool {
+	return strings.HasPrefix(id, "https://") || strings.HasPrefix(id, "http://")
+}
+
+// splitImportID splits a "/" separated import ID into exactly n non-empty
+// parts, format is only used in the error message
+func splitImportID(id string, n int, format string) ([]string, error) {
+	parts := strings.SplitN(id, "/", n)
+	if len(parts) != n {
+		return nil, fmt.Errorf("unexpected import ID %q, expected %s", id, format)
+	}
+
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("unexpected import ID %q, expected %s", id, format)
+		}
+	}
+
+	return parts, nil
+}
+
+// splitHostPort splits "ip:port" into its parts, the port is either a number
+// or "any" which is returned as -1 like getNumericPort does
+func splitHostPort(value string) (string, int, error) {
+	index := strings.LastIndex(value, ":")
+	if index <= 0 || index == len(value)-1 {
+		return "", 0, fmt.Errorf("expected <ip>:<port>, got %q", value)
+	}
+
+	portString := value[index+1:]
+	port := getNumericPort(portString)
+	if port < 0 && portString != "any" {
+		return "", 0, fmt.Errorf("invalid port in %q", value)
+	}
+
+	return value[:index], port, nil
+}
+
+// suppressUnreadableAfterImport hides the diff on attributes that are only
+// used at creation time and cannot be read back from vCloud, so an imported
+// resource is not replaced on the next apply
+func suppressUnreadableAfterImport(k, old, new string, d *schema.ResourceData) bool {
+	return old == "" && d.Id() != ""
+}
@@ -0,0 +1,72 @@
+package vcd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitImportID(t *testing.T) {
+	cases := []struct {
+		id       string
+		n        int
+		expected []string
+		err      bool
+	}{
+		{"vapp/vm", 2, []string{"vapp", "vm"}, false},
+		{"edge/10.0.0.1:80", 2, []string{"edge", "10.0.0.1:80"}, false},
+		{"vapp/vm/with/slashes", 2, []string{"vapp", "vm/with/slashes"}, false},
+		{"vapp", 2, nil, true},
+		{"vapp/", 2, nil, true},
+		{"/vm", 2, nil, true},
+	}
+
+	for _, c := range cases {
+		parts, err := splitImportID(c.id, c.n, "<a>/<b>")
+		if c.err {
+			if err == nil {
+				t.Errorf("expected an error for %q", c.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", c.id, err)
+			continue
+		}
+		if !reflect.DeepEqual(parts, c.expected) {
+			t.Errorf("expected %#v for %q, got %#v", c.expected, c.id, parts)
+		}
+	}
+}
+
+func TestSplitHostPort(t *testing.T) {
+	cases := []struct {
+		value string
+		ip    string
+		port  int
+		err   bool
+	}{
+		{"10.0.0.1:80", "10.0.0.1", 80, false},
+		{"10.0.0.1:any", "10.0.0.1", -1, false},
+		{"10.0.0.1", "", 0, true},
+		{"10.0.0.1:", "", 0, true},
+		{":80", "", 0, true},
+		{"10.0.0.1:http", "", 0, true},
+	}
+
+	for _, c := range cases {
+		ip, port, err := splitHostPort(c.value)
+		if c.err {
+			if err == nil {
+				t.Errorf("expected an error for %q", c.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", c.value, err)
+			continue
+		}
+		if ip != c.ip || port != c.port {
+			t.Errorf("expected %s:%d for %q, got %s:%d", c.ip, c.port, c.value, ip, port)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// loadBalancerService is the load balancer of an edge gateway, the vendored
+// types only hold a single pool and virtual server
+type loadBalancerService struct {
+	IsEnabled     bool               `xml:"IsEnabled"`
+	Pool          []*lbPool          `xml:"Pool,omitempty"`
+	VirtualServer []*lbVirtualServer `xml:"VirtualServer,omitempty"`
+}
+
+type lbPool struct {
+	ID           string               `xml:"Id,omitempty"`
+	Name         string               `xml:"Name"`
+	Description  string               `xml:"Description,omitempty"`
+	ServicePort  []*lbPoolServicePort `xml:"ServicePort"`
+	Member       []*lbPoolMember      `xml:"Member,omitempty"`
+	Operational  bool                 `xml:"Operational,omitempty"`
+	ErrorDetails string               `xml:"ErrorDetails,omitempty"`
+}
+
+type lbPoolServicePort struct {
+	IsEnabled       bool                       `xml:"IsEnabled"`
+	Protocol        string                     `xml:"Protocol"`
+	Algorithm       string                     `xml:"Algorithm"`
+	Port            string                     `xml:"Port"`
+	HealthCheckPort string                     `xml:"HealthCheckPort,omitempty"`
+	HealthCheck     []*types.LBPoolHealthCheck `xml:"HealthCheck,omitempty"`
+}
+
+type lbPoolMember struct {
+	IPAddress   string               `xml:"IpAddress"`
+	Weight      string               `xml:"Weight"`
+	ServicePort []*lbPoolServicePort `xml:"ServicePort,omitempty"`
+}
+
+type lbVirtualServer struct {
+	IsEnabled             bool                  `xml:"IsEnabled"`
+	Name                  string                `xml:"Name"`
+	Description           string                `xml:"Description,omitempty"`
+	Interface             *types.Reference      `xml:"Interface"`
+	IPAddress             string                `xml:"IpAddress"`
+	ServiceProfile        []*lbServiceProfile   `xml:"ServiceProfile"`
+	Logging               bool                  `xml:"Logging"`
+	Pool                  string                `xml:"Pool"`
+	LoadBalancerTemplates *types.VendorTemplate `xml:"LoadBalancerTemplates,omitempty"`
+}
+
+// lbServiceProfile is a service profile of a virtual server, the vendored
+// type drops the IsEnabled flag of the disabled ones
+type lbServiceProfile struct {
+	IsEnabled   bool                 `xml:"IsEnabled"`
+	Protocol    string               `xml:"Protocol"`
+	Port        string               `xml:"Port"`
+	Persistence *types.LBPersistence `xml:"Persistence,omitempty"`
+}
+
+var lbProtocols = []string{"HTTP", "HTTPS", "TCP"}
+
+func lbPoolServicePortSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"protocol": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(lbProtocols, false),
+			},
+			"algorithm": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{"IP_HASH", "ROUND_ROBIN", "URI", "LEAST_CONN"}, false),
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntBetween(1, 65535),
+			},
+			// The port of the service when not set
+			"health_check_port": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+			"health_check": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mode": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"TCP", "HTTP", "SSL"}, false),
+						},
+						"uri": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"healthy_threshold": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+						},
+						"unhealthy_threshold": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+						},
+						"interval": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+						},
+						"timeout": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func lbVirtualServerServiceProfileSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"protocol": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(lbProtocols, false),
+			},
+			"port": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntBetween(1, 65535),
+			},
+			"persistence": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"method": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice([]string{"COOKIE", "SSL_SESSION_ID"}, false),
+						},
+						"cookie_name": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"cookie_mode": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringInSlice([]string{"INSERT", "PREFIX", "APP"}, false),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// lbNumber converts the numbers vCD returns as strings, 0 is returned for
+// unset values
+func lbNumber(value string) int {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// lbString converts a number to the string vCD expects, 0 is unset
+func lbString(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func expandLBPoolServicePorts(list []interface{}) ([]*lbPoolServicePort, error) {
+	ports := make([]*lbPoolServicePort, 0, len(list))
+	protocols := make(map[string]bool)
+
+	for _, item := range list {
+		data := item.(map[string]interface{})
+		protocol := data["protocol"].(string)
+		if protocols[protocol] {
+			return nil, fmt.Errorf("the %s service port is set more than once", protocol)
+		}
+		protocols[protocol] = true
+
+		port := &lbPoolServicePort{
+			IsEnabled:       true,
+			Protocol:        protocol,
+			Algorithm:       data["algorithm"].(string),
+			Port:            lbString(data["port"].(int)),
+			HealthCheckPort: lbString(data["health_check_port"].(int)),
+		}
+
+		for _, healthCheckItem := range data["health_check"].([]interface{}) {
+			healthCheck := healthCheckItem.(map[string]interface{})
+			port.HealthCheck = append(port.HealthCheck, &types.LBPoolHealthCheck{
+				Mode:              healthCheck["mode"].(string),
+				URI:               healthCheck["uri"].(string),
+				HealthThreshold:   lbString(healthCheck["healthy_threshold"].(int)),
+				UnhealthThreshold: lbString(healthCheck["unhealthy_threshold"].(int)),
+				Interval:          lbString(healthCheck["interval"].(int)),
+				Timeout:           lbString(healthCheck["timeout"].(int)),
+			})
+		}
+
+		ports = append(ports, port)
+	}
+
+	return ports, nil
+}
+
+// flattenLBPoolServicePorts returns the enabled service ports, vCD keeps a
+// disabled service port for each protocol which is not used
+func flattenLBPoolServicePorts(ports []*lbPoolServicePort) []interface{} {
+	flattened := make([]interface{}, 0, len(ports))
+	for _, port := range ports {
+		if !port.IsEnabled {
+			continue
+		}
+
+		healthChecks := make([]interface{}, 0, len(port.HealthCheck))
+		for _, healthCheck := range port.HealthCheck {
+			healthChecks = append(healthChecks, map[string]interface{}{
+				"mode":                healthCheck.Mode,
+				"uri":                 healthCheck.URI,
+				"healthy_threshold":   lbNumber(healthCheck.HealthThreshold),
+				"unhealthy_threshold": lbNumber(healthCheck.UnhealthThreshold),
+				"interval":            lbNumber(healthCheck.Interval),
+				"timeout":             lbNumber(healthCheck.Timeout),
+			})
+		}
+
+		flattened = append(flattened, map[string]interface{}{
+			"protocol":          port.Protocol,
+			"algorithm":         port.Algorithm,
+			"port":              lbNumber(port.Port),
+			"health_check_port": lbNumber(port.HealthCheckPort),
+			"health_check":      healthChecks,
+		})
+	}
+	return flattened
+}
+
+func expandLBServiceProfiles(list []interface{}) ([]*lbServiceProfile, error) {
+	profiles := make([]*lbServiceProfile, 0, len(list))
+	protocols := make(map[string]bool)
+
+	for _, item := range list {
+		data := item.(map[string]interface{})
+		protocol := data["protocol"].(string)
+		if protocols[protocol] {
+			return nil, fmt.Errorf("the %s service profile is set more than once", protocol)
+		}
+		protocols[protocol] = true
+
+		profile := &lbServiceProfile{
+			IsEnabled: true,
+			Protocol:  protocol,
+			Port:      lbString(data["port"].(int)),
+		}
+		for _, persistenceItem := range data["persistence"].([]interface{}) {
+			persistence := persistenceItem.(map[string]interface{})
+			profile.Persistence = &types.LBPersistence{
+				Method:     persistence["method"].(string),
+				CookieName: persistence["cookie_name"].(string),
+				CookieMode: persistence["cookie_mode"].(string),
+			}
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// flattenLBServiceProfiles returns the enabled service profiles, like the
+// service ports of the pools vCD keeps a disabled one for each protocol
+func flattenLBServiceProfiles(profiles []*lbServiceProfile) []interface{} {
+	flattened := make([]interface{}, 0, len(profiles))
+	for _, profile := range profiles {
+		if !profile.IsEnabled {
+			continue
+		}
+
+		persistence := make([]interface{}, 0, 1)
+		if profile.Persistence != nil && profile.Persistence.Method != "" {
+			persistence = append(persistence, map[string]interface{}{
+				"method":      profile.Persistence.Method,
+				"cookie_name": profile.Persistence.CookieName,
+				"cookie_mode": profile.Persistence.CookieMode,
+			})
+		}
+
+		flattened = append(flattened, map[string]interface{}{
+			"protocol":    profile.Protocol,
+			"port":        lbNumber(profile.Port),
+			"persistence": persistence,
+		})
+	}
+	return flattened
+}
+
+func findLBPool(service *loadBalancerService, name string) *lbPool {
+	if service == nil {
+		return nil
+	}
+	for _, pool := range service.Pool {
+		if pool.Name == name {
+			return pool
+		}
+	}
+	return nil
+}
+
+func findLBVirtualServer(service *loadBalancerService, name string) *lbVirtualServer {
+	if service == nil {
+		return nil
+	}
+	for _, virtualServer := range service.VirtualServer {
+		if virtualServer.Name == name {
+			return virtualServer
+		}
+	}
+	return nil
+}
+
+// readLoadBalancerService returns the load balancer of the edge gateway of
+// the resource
+func readLoadBalancerService(d *schema.ResourceData, meta interface{}) (*govcd.EdgeGateway, *loadBalancerService, error) {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Unable to find edge gateway")
+	}
+
+	services, err := readEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &edgeGateway, services.LoadBalancerService, nil
+}
+
+// changeLoadBalancerService applies change to the load balancer of the edge
+// gateway of the resource. The load balancer is read again on every retry,
+// the pools and virtual servers not touched by change are sent back as they
+// are. remove is set when the resource is deleted.
+func changeLoadBalancerService(d *schema.ResourceData, meta interface{}, remove bool, change func(*types.EdgeGateway, *loadBalancerService) error) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	log.Printf("[INFO] Changing load balancer of edge gateway %s", edgeGateway.EdgeGateway.Name)
+
+	ctx, cancel := vcdClient.changeContext(d, remove)
+	defer cancel()
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		services, err := readEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF)
+		if err != nil {
+			return govcd.Task{}, err
+		}
+
+		service := services.LoadBalancerService
+		if service == nil {
+			service = &loadBalancerService{}
+		}
+		service.IsEnabled = true
+
+		err = change(edgeGateway.EdgeGateway, service)
+		if err != nil {
+			return govcd.Task{}, err
+		}
+
+		// The state of the pools is not part of their configuration
+		for _, pool := range service.Pool {
+			pool.Operational = false
+			pool.ErrorDetails = ""
+		}
+
+		return configureEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF, &edgeGatewayServices{
+			LoadBalancerService: service,
+		})
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot change load balancer: edgeGateway=%s", edgeGateway.EdgeGateway.Name)
+	}
+
+	return nil
+}
+
+// mergeLBPoolServicePorts returns the desired service ports followed by the
+// ports of the other protocols, which are disabled
+func mergeLBPoolServicePorts(existing, desired []*lbPoolServicePort) []*lbPoolServicePort {
+	merged := append([]*lbPoolServicePort{}, desired...)
+	for _, port := range existing {
+		found := false
+		for _, desiredPort := range desired {
+			found = found || desiredPort.Protocol == port.Protocol
+		}
+		if !found {
+			port.IsEnabled = false
+			merged = append(merged, port)
+		}
+	}
+	return merged
+}
+
+// mergeLBServiceProfiles returns the desired service profiles followed by
+// the profiles of the other protocols, which are disabled
+func mergeLBServiceProfiles(existing, desired []*lbServiceProfile) []*lbServiceProfile {
+	merged := append([]*lbServiceProfile{}, desired...)
+	for _, profile := range existing {
+		found := false
+		for _, desiredProfile := range desired {
+			found = found || desiredProfile.Protocol == profile.Protocol
+		}
+		if !found {
+			profile.IsEnabled = false
+			merged = append(merged, profile)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,136 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestFlattenLBPoolServicePorts(t *testing.T) {
+	service := testReadLoadBalancerService(t)
+
+	if len(service.Pool) != 2 || len(service.VirtualServer) != 1 {
+		t.Fatalf("expected 2 pools and 1 virtual server, got %d and %d", len(service.Pool), len(service.VirtualServer))
+	}
+
+	ports := flattenLBPoolServicePorts(findLBPool(service, "masters").ServicePort)
+	expected := []interface{}{
+		map[string]interface{}{
+			"protocol":          "TCP",
+			"algorithm":         "LEAST_CONN",
+			"port":              6443,
+			"health_check_port": 6443,
+			"health_check": []interface{}{
+				map[string]interface{}{
+					"mode":                "TCP",
+					"uri":                 "",
+					"healthy_threshold":   2,
+					"unhealthy_threshold": 3,
+					"interval":            5,
+					"timeout":             15,
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ports, expected) {
+		t.Errorf("expected %#v, got %#v", expected, ports)
+	}
+}
+
+func TestFlattenLBServiceProfiles(t *testing.T) {
+	service := testReadLoadBalancerService(t)
+
+	profiles := flattenLBServiceProfiles(findLBVirtualServer(service, "api").ServiceProfile)
+	expected := []interface{}{
+		map[string]interface{}{
+			"protocol":    "TCP",
+			"port":        6443,
+			"persistence": []interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(profiles, expected) {
+		t.Errorf("expected %#v, got %#v", expected, profiles)
+	}
+}
+
+func TestMergeLBPoolServicePorts(t *testing.T) {
+	service := testReadLoadBalancerService(t)
+	pool := findLBPool(service, "masters")
+
+	merged := mergeLBPoolServicePorts(pool.ServicePort, []*lbPoolServicePort{
+		{IsEnabled: true, Protocol: "HTTP", Algorithm: "ROUND_ROBIN", Port: "8080"},
+	})
+
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 service ports, got %d", len(merged))
+	}
+	if merged[0].Protocol != "HTTP" || !merged[0].IsEnabled || merged[0].Port != "8080" {
+		t.Errorf("expected the enabled HTTP port first, got %#v", merged[0])
+	}
+	if merged[1].Protocol != "TCP" || merged[1].IsEnabled {
+		t.Errorf("expected the TCP port to be disabled, got %#v", merged[1])
+	}
+}
+
+func TestExpandLBPoolMemberServicePorts(t *testing.T) {
+	service := testReadLoadBalancerService(t)
+	pool := findLBPool(service, "masters")
+
+	d := schema.TestResourceDataRaw(t, resourceVcdLBPool().Schema, map[string]interface{}{
+		"edge_gateway": "edge",
+		"name":         "masters",
+		"service_port": []interface{}{
+			map[string]interface{}{
+				"protocol": "TCP",
+				"port":     6443,
+			},
+		},
+		"member": []interface{}{
+			map[string]interface{}{"ip_address": "10.0.0.11"},
+			map[string]interface{}{"ip_address": "10.0.0.13"},
+		},
+	})
+
+	if err := expandLBPool(d, pool); err != nil {
+		t.Fatalf("cannot expand pool: %v", err)
+	}
+
+	if len(pool.Member) != 2 {
+		t.Fatalf("expected 2 members, got %d", len(pool.Member))
+	}
+	for _, member := range pool.Member {
+		switch member.IPAddress {
+		case "10.0.0.11":
+			if len(member.ServicePort) != 1 || member.ServicePort[0].Port != "16443" || member.ServicePort[0].HealthCheckPort != "16444" {
+				t.Errorf("expected the service port of %s to be kept, got %#v", member.IPAddress, member.ServicePort)
+			}
+		case "10.0.0.13":
+			if len(member.ServicePort) != 0 {
+				t.Errorf("expected no service port on the new member %s, got %#v", member.IPAddress, member.ServicePort)
+			}
+		default:
+			t.Errorf("unexpected member %s", member.IPAddress)
+		}
+	}
+}
+
+func TestLoadBalancerServiceXML(t *testing.T) {
+	service := testReadLoadBalancerService(t)
+
+	output, err := xml.Marshal(&edgeGatewayServices{LoadBalancerService: service})
+	if err != nil {
+		t.Fatalf("cannot marshal edge gateway services: %v", err)
+	}
+
+	for _, fragment := range []string{
+		"<Name>ingress</Name>",
+		"<ServiceProfile><IsEnabled>false</IsEnabled><Protocol>HTTP</Protocol>",
+	} {
+		if !strings.Contains(string(output), fragment) {
+			t.Errorf("expected %s in %s", fragment, output)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+const (
+	mimeMetadata = "application/vnd.vmware.vcloud.metadata+xml"
+
+	metadataDomainGeneral = "GENERAL"
+	metadataDomainSystem  = "SYSTEM"
+
+	metadataVisibilityReadWrite = "READWRITE"
+)
+
+// metadataTypes maps the type of a metadata entry to its xsi:type
+var metadataTypes = map[string]string{
+	"string":   "MetadataStringValue",
+	"number":   "MetadataNumberValue",
+	"boolean":  "MetadataBooleanValue",
+	"datetime": "MetadataDateTimeValue",
+}
+
+// MetadataSchema is the metadata attribute of the resources, string values
+// in the general domain
+func MetadataSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// MetadataEntrySchema is the metadata_entry attribute of the resources, the
+// entries with a type, a domain or a visibility of their own
+func MetadataEntrySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"key": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"value": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"type": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "string",
+					ValidateFunc: validation.StringInSlice([]string{"string", "number", "boolean", "datetime"}, false),
+				},
+				"domain": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      metadataDomainGeneral,
+					ValidateFunc: validation.StringInSlice([]string{metadataDomainGeneral, metadataDomainSystem}, false),
+				},
+				"visibility": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      metadataVisibilityReadWrite,
+					ValidateFunc: validation.StringInSlice([]string{metadataVisibilityReadWrite, "READONLY", "PRIVATE"}, false),
+				},
+			},
+		},
+	}
+}
+
+type metadataEntry struct {
+	Key        string
+	Value      string
+	Type       string
+	Domain     string
+	Visibility string
+}
+
+// vcdMetadata is the metadata of an entity, the vendored types only cover
+// a single value without its domain
+type vcdMetadata struct {
+	MetadataEntry []*vcdMetadataEntry `xml:"MetadataEntry"`
+}
+
+type vcdMetadataEntry struct {
+	Domain     *vcdMetadataDomain `xml:"Domain"`
+	Key        string             `xml:"Key"`
+	TypedValue struct {
+		Type  string `xml:"http://www.w3.org/2001/XMLSchema-instance type,attr"`
+		Value string `xml:"Value"`
+	} `xml:"TypedValue"`
+}
+
+type vcdMetadataDomain struct {
+	Visibility string `xml:"visibility,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type metadataParams struct {
+	XMLName       xml.Name               `xml:"Metadata"`
+	Xmlns         string                 `xml:"xmlns,attr"`
+	Xsi           string                 `xml:"xmlns:xsi,attr"`
+	MetadataEntry []*metadataEntryParams `xml:"MetadataEntry"`
+}
+
+type metadataEntryParams struct {
+	Domain     *vcdMetadataDomain `xml:"Domain"`
+	Key        string             `xml:"Key"`
+	TypedValue *types.TypedValue  `xml:"TypedValue"`
+}
+
+// convertMetadataEntry converts an entry read from vCD, the domain is only
+// returned since API version 5.1
+func convertMetadataEntry(entry *vcdMetadataEntry) metadataEntry {
+	converted := metadataEntry{
+		Key:        entry.Key,
+		Value:      entry.TypedValue.Value,
+		Type:       "string",
+		Domain:     metadataDomainGeneral,
+		Visibility: metadataVisibilityReadWrite,
+	}
+
+	xsiType := entry.TypedValue.Type
+	if index := strings.LastIndex(xsiType, ":"); index >= 0 {
+		xsiType = xsiType[index+1:]
+	}
+	for name, value := range metadataTypes {
+		if value == xsiType {
+			converted.Type = name
+		}
+	}
+
+	if entry.Domain != nil {
+		converted.Domain = strings.TrimSpace(entry.Domain.Value)
+		converted.Visibility = entry.Domain.Visibility
+	}
+
+	return converted
+}
+
+// expandMetadata merges the metadata and the metadata_entry attributes to
+// the entries by key
+func expandMetadata(metadata map[string]interface{}, entries []interface{}) (map[string]metadataEntry, error) {
+	expanded := make(map[string]metadataEntry, len(metadata)+len(entries))
+
+	for key, value := range metadata {
+		expanded[key] = metadataEntry{
+			Key:        key,
+			Value:      value.(string),
+			Type:       "string",
+			Domain:     metadataDomainGeneral,
+			Visibility: metadataVisibilityReadWrite,
+		}
+	}
+
+	for _, item := range entries {
+		entry := item.(map[string]interface{})
+		key := entry["key"].(string)
+		if _, ok := expanded[key]; ok {
+			return nil, fmt.Errorf("metadata key '%s' is set more than once", key)
+		}
+
+		expanded[key] = metadataEntry{
+			Key:        key,
+			Value:      entry["value"].(string),
+			Type:       entry["type"].(string),
+			Domain:     entry["domain"].(string),
+			Visibility: entry["visibility"].(string),
+		}
+		if expanded[key].Domain == metadataDomainGeneral && expanded[key].Visibility != metadataVisibilityReadWrite {
+			return nil, fmt.Errorf("metadata key '%s' in the %s domain must be %s", key, metadataDomainGeneral, metadataVisibilityReadWrite)
+		}
+	}
+
+	return expanded, nil
+}
+
+// sameMetadataValue compares values of a type the way vCD does, e.g. vCD
+// returns date times with milliseconds
+func sameMetadataValue(valueType, a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	switch valueType {
+	case "number":
+		x, okX := new(big.Float).SetString(a)
+		y, okY := new(big.Float).SetString(b)
+		return okX && okY && x.Cmp(y) == 0
+	case "boolean":
+		x, errX := strconv.ParseBool(a)
+		y, errY := strconv.ParseBool(b)
+		return errX == nil && errY == nil && x == y
+	case "datetime":
+		x, errX := time.Parse(time.RFC3339Nano, a)
+		y, errY := time.Parse(time.RFC3339Nano, b)
+		return errX == nil && errY == nil && x.Equal(y)
+	}
+
+	return false
+}
+
+func sameMetadataEntry(a, b metadataEntry) bool {
+	return a.Type == b.Type && a.Domain == b.Domain && a.Visibility == b.Visibility &&
+		sameMetadataValue(a.Type, a.Value, b.Value)
+}
+
+// planMetadata returns the entries to set and to delete to bring the
+// metadata on vCD to the desired entries. Only the entries which were managed
+// before are deleted, the entries added by others are kept.
+func planMetadata(current map[string]metadataEntry, managed []string, desired map[string]metadataEntry) ([]metadataEntry, []metadataEntry) {
+	set := make([]metadataEntry, 0)
+	deleted := make([]metadataEntry, 0)
+
+	for _, key := range sortedMetadataKeys(desired) {
+		entry := desired[key]
+		currentEntry, ok := current[key]
+		if ok && sameMetadataEntry(currentEntry, entry) {
+			continue
+		}
+		// An entry cannot move to another domain
+		if ok && currentEntry.Domain != entry.Domain {
+			deleted = append(deleted, currentEntry)
+		}
+		set = append(set, entry)
+	}
+
+	sort.Strings(managed)
+	for _, key := range managed {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if currentEntry, ok := current[key]; ok {
+			deleted = append(deleted, currentEntry)
+		}
+	}
+
+	return set, deleted
+}
+
+func sortedMetadataKeys(m map[string]metadataEntry) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func metadataEntryHREF(href string, entry metadataEntry) string {
+	if entry.Domain == metadataDomainSystem {
+		return href + "/metadata/" + metadataDomainSystem + "/" + url.PathEscape(entry.Key)
+	}
+	return href + "/metadata/" + url.PathEscape(entry.Key)
+}
+
+func readMetadata(client *govcd.Client, href string) (map[string]metadataEntry, error) {
+	metadata := &vcdMetadata{}
+	err := getXML(client, href+"/metadata", metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read metadata: %s", href)
+	}
+
+	entries := make(map[string]metadataEntry, len(metadata.MetadataEntry))
+	for _, entry := range metadata.MetadataEntry {
+		entries[entry.Key] = convertMetadataEntry(entry)
+	}
+	return entries, nil
+}
+
+// mergeMetadata adds or updates the given entries in a single task
+func mergeMetadata(client *govcd.Client, href string, entries []metadataEntry) (govcd.Task, error) {
+	params := &metadataParams{
+		Xmlns: types.XMLNamespaceXMLNS,
+		Xsi:   types.XMLNamespaceXSI,
+	}
+	for _, entry := range entries {
+		params.MetadataEntry = append(params.MetadataEntry, &metadataEntryParams{
+			Domain: &vcdMetadataDomain{Visibility: entry.Visibility, Value: entry.Domain},
+			Key:    entry.Key,
+			TypedValue: &types.TypedValue{
+				XsiType: metadataTypes[entry.Type],
+				Value:   entry.Value,
+			},
+		})
+	}
+
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, http.MethodPost, href+"/metadata", mimeMetadata, params, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
+
+func deleteMetadata(client *govcd.Client, href string, entry metadataEntry) (govcd.Task, error) {
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, http.MethodDelete, metadataEntryHREF(href, entry), "", nil, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
+
+// managedMetadataKeys returns the keys of the metadata and the
+// metadata_entry attributes
+func managedMetadataKeys(metadata map[string]interface{}, entries []interface{}) []string {
+	keys := make([]string, 0, len(metadata)+len(entries))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	for _, item := range entries {
+		keys = append(keys, item.(map[string]interface{})["key"].(string))
+	}
+	return keys
+}
+
+// configureMetadata applies the changes of the metadata and the
+// metadata_entry attributes to the entity at href
+func configureMetadata(d *schema.ResourceData, href string, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	if !d.HasChange("metadata") && !d.HasChange("metadata_entry") {
+		return nil
+	}
+
+	oldMetadata, newMetadata := d.GetChange("metadata")
+	oldEntries, newEntries := d.GetChange("metadata_entry")
+
+	desired, err := expandMetadata(newMetadata.(map[string]interface{}), newEntries.(*schema.Set).List())
+	if err != nil {
+		return err
+	}
+	managed := managedMetadataKeys(oldMetadata.(map[string]interface{}), oldEntries.(*schema.Set).List())
+
+	current, err := readMetadata(&vcdClient.Client, href)
+	if err != nil {
+		return err
+	}
+
+	set, deleted := planMetadata(current, managed, desired)
+
+	ctx, cancel := vcdClient.changeContext(d, false)
+	defer cancel()
+
+	for _, entry := range deleted {
+		entry := entry
+		log.Printf("[TRACE] Deleting metadata '%s' of %s", entry.Key, href)
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return deleteMetadata(&vcdClient.Client, href, entry)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot delete metadata: key=%s", entry.Key)
+		}
+	}
+
+	if len(set) > 0 {
+		log.Printf("[TRACE] Setting %d metadata entries of %s", len(set), href)
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return mergeMetadata(&vcdClient.Client, href, set)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot set metadata: %s", href)
+		}
+	}
+
+	return nil
+}
+
+// flattenMetadata returns the metadata and the metadata_entry attributes for
+// the managed keys, the value of the state is kept when vCD returns the same
+// value in another notation
+func flattenMetadata(current map[string]metadataEntry, metadata map[string]interface{}, entries []interface{}) (map[string]interface{}, []interface{}) {
+	flattenedMetadata := make(map[string]interface{})
+	for key, value := range metadata {
+		entry, ok := current[key]
+		if !ok {
+			continue
+		}
+		flattenedMetadata[key] = entry.Value
+		if sameMetadataValue(entry.Type, entry.Value, value.(string)) {
+			flattenedMetadata[key] = value
+		}
+	}
+
+	flattenedEntries := make([]interface{}, 0, len(entries))
+	for _, item := range entries {
+		stateEntry := item.(map[string]interface{})
+		entry, ok := current[stateEntry["key"].(string)]
+		if !ok {
+			continue
+		}
+
+		value := entry.Value
+		if sameMetadataValue(entry.Type, entry.Value, stateEntry["value"].(string)) {
+			value = stateEntry["value"].(string)
+		}
+		flattenedEntries = append(flattenedEntries, map[string]interface{}{
+			"key":        entry.Key,
+			"value":      value,
+			"type":       entry.Type,
+			"domain":     entry.Domain,
+			"visibility": entry.Visibility,
+		})
+	}
+
+	return flattenedMetadata, flattenedEntries
+}
+
+// readMetadataAttributes sets the metadata and the metadata_entry
+// attributes, only the entries already managed are read as other tools may
+// tag the entity as well
+func readMetadataAttributes(d *schema.ResourceData, href string, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	metadata := d.Get("metadata").(map[string]interface{})
+	entries := d.Get("metadata_entry").(*schema.Set).List()
+	if len(metadata) == 0 && len(entries) == 0 {
+		return nil
+	}
+
+	current, err := readMetadata(&vcdClient.Client, href)
+	if err != nil {
+		return err
+	}
+
+	flattenedMetadata, flattenedEntries := flattenMetadata(current, metadata, entries)
+
+	err = d.Set("metadata", flattenedMetadata)
+	if err != nil {
+		return err
+	}
+	return d.Set("metadata_entry", flattenedEntries)
+}
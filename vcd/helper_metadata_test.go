@@ -0,0 +1,133 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func TestConvertMetadataEntry(t *testing.T) {
+	current := testReadMetadata(t)
+
+	expected := map[string]metadataEntry{
+		"cluster":           {Key: "cluster", Value: "prod-1", Type: "string", Domain: "GENERAL", Visibility: "READWRITE"},
+		"owner":             {Key: "owner", Value: "team-a", Type: "string", Domain: "GENERAL", Visibility: "READWRITE"},
+		"expires":           {Key: "expires", Value: "2020-01-01T00:00:00.000Z", Type: "datetime", Domain: "SYSTEM", Visibility: "READONLY"},
+		"managed-elsewhere": {Key: "managed-elsewhere", Value: "true", Type: "boolean", Domain: "GENERAL", Visibility: "READWRITE"},
+	}
+	if !reflect.DeepEqual(current, expected) {
+		t.Errorf("expected %#v, got %#v", expected, current)
+	}
+}
+
+func TestExpandMetadata(t *testing.T) {
+	_, err := expandMetadata(map[string]interface{}{"cluster": "prod-1"}, []interface{}{
+		map[string]interface{}{"key": "cluster", "value": "1", "type": "number", "domain": "GENERAL", "visibility": "READWRITE"},
+	})
+	if err == nil {
+		t.Errorf("expected an error for a key set twice")
+	}
+
+	_, err = expandMetadata(nil, []interface{}{
+		map[string]interface{}{"key": "cluster", "value": "prod-1", "type": "string", "domain": "GENERAL", "visibility": "PRIVATE"},
+	})
+	if err == nil {
+		t.Errorf("expected an error for a private entry in the general domain")
+	}
+}
+
+func TestPlanMetadata(t *testing.T) {
+	current := testReadMetadata(t)
+
+	desired, err := expandMetadata(map[string]interface{}{
+		"cluster": "prod-2",
+		"cost":    "42",
+	}, []interface{}{
+		map[string]interface{}{"key": "expires", "value": "2020-01-01T00:00:00Z", "type": "datetime", "domain": "SYSTEM", "visibility": "READONLY"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	set, deleted := planMetadata(current, []string{"cluster", "owner", "expires", "gone"}, desired)
+
+	expectedSet := []metadataEntry{
+		{Key: "cluster", Value: "prod-2", Type: "string", Domain: "GENERAL", Visibility: "READWRITE"},
+		{Key: "cost", Value: "42", Type: "string", Domain: "GENERAL", Visibility: "READWRITE"},
+	}
+	if !reflect.DeepEqual(set, expectedSet) {
+		t.Errorf("expected to set %#v, got %#v", expectedSet, set)
+	}
+
+	expectedDeleted := []metadataEntry{current["owner"]}
+	if !reflect.DeepEqual(deleted, expectedDeleted) {
+		t.Errorf("expected to delete %#v, got %#v", expectedDeleted, deleted)
+	}
+}
+
+func TestPlanMetadataDomainChange(t *testing.T) {
+	current := testReadMetadata(t)
+
+	desired, err := expandMetadata(nil, []interface{}{
+		map[string]interface{}{"key": "owner", "value": "team-a", "type": "string", "domain": "SYSTEM", "visibility": "PRIVATE"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	set, deleted := planMetadata(current, []string{"owner"}, desired)
+	if len(set) != 1 || set[0].Domain != "SYSTEM" {
+		t.Errorf("expected the entry to be set in the system domain, got %#v", set)
+	}
+	if len(deleted) != 1 || deleted[0].Domain != "GENERAL" {
+		t.Errorf("expected the entry to be deleted from the general domain, got %#v", deleted)
+	}
+}
+
+func TestFlattenMetadata(t *testing.T) {
+	current := testReadMetadata(t)
+
+	metadata, entries := flattenMetadata(current, map[string]interface{}{
+		"cluster": "prod-0",
+		"removed": "value",
+	}, []interface{}{
+		map[string]interface{}{"key": "expires", "value": "2020-01-01T00:00:00Z", "type": "datetime", "domain": "SYSTEM", "visibility": "READONLY"},
+	})
+
+	expectedMetadata := map[string]interface{}{"cluster": "prod-1"}
+	if !reflect.DeepEqual(metadata, expectedMetadata) {
+		t.Errorf("expected %#v, got %#v", expectedMetadata, metadata)
+	}
+
+	expectedEntries := []interface{}{
+		map[string]interface{}{"key": "expires", "value": "2020-01-01T00:00:00Z", "type": "datetime", "domain": "SYSTEM", "visibility": "READONLY"},
+	}
+	if !reflect.DeepEqual(entries, expectedEntries) {
+		t.Errorf("expected %#v, got %#v", expectedEntries, entries)
+	}
+}
+
+func TestMetadataParamsXML(t *testing.T) {
+	params := &metadataParams{
+		Xmlns: types.XMLNamespaceXMLNS,
+		Xsi:   types.XMLNamespaceXSI,
+		MetadataEntry: []*metadataEntryParams{{
+			Domain:     &vcdMetadataDomain{Visibility: "READWRITE", Value: "GENERAL"},
+			Key:        "cost",
+			TypedValue: &types.TypedValue{XsiType: metadataTypes["number"], Value: "42"},
+		}},
+	}
+
+	output, err := xml.Marshal(params)
+	if err != nil {
+		t.Fatalf("cannot marshal metadata: %v", err)
+	}
+
+	expected := `<MetadataEntry><Domain visibility="READWRITE">GENERAL</Domain><Key>cost</Key><TypedValue xsi:type="MetadataNumberValue"><Value>42</Value></TypedValue></MetadataEntry>`
+	if !strings.Contains(string(output), expected) {
+		t.Errorf("expected %s in %s", expected, output)
+	}
+}
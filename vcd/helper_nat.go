@@ -0,0 +1,228 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+var natPortPattern = regexp.MustCompile(`^(any|\d+|\d+-\d+)$`)
+
+// validateNatPort accepts a port, a port range like "8000-8080" or "any"
+func validateNatPort(i interface{}, k string) (s []string, es []error) {
+	v, ok := i.(string)
+	if !ok {
+		es = append(es, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+	if !natPortPattern.MatchString(v) {
+		es = append(es, fmt.Errorf("expected %s to be a port, a port range or any, got %s", k, v))
+	}
+	return
+}
+
+// edgeGatewayNatRules returns the NAT rules of the edge gateway
+func edgeGatewayNatRules(edgeGateway *types.EdgeGateway) []*types.NatRule {
+	if edgeGateway.Configuration == nil ||
+		edgeGateway.Configuration.EdgeGatewayServiceConfiguration == nil ||
+		edgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService == nil {
+		return nil
+	}
+	return edgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService.NatRule
+}
+
+// find1to1NatRules returns the SNAT and DNAT rules of the one to one mapping
+// between the internal and the external IP, a rule is nil when it is missing
+func find1to1NatRules(rules []*types.NatRule, internalIP, externalIP string) (snat, dnat *types.NatRule) {
+	for _, rule := range rules {
+		natRule := rule.GatewayNatRule
+		if natRule == nil {
+			continue
+		}
+
+		switch rule.RuleType {
+		case "SNAT":
+			if snat == nil && natRule.OriginalIP == internalIP && natRule.TranslatedIP == externalIP {
+				snat = rule
+			}
+		case "DNAT":
+			if dnat == nil && natRule.OriginalIP == externalIP && natRule.TranslatedIP == internalIP &&
+				strings.EqualFold(natRule.OriginalPort, "any") &&
+				strings.EqualFold(natRule.TranslatedPort, "any") &&
+				strings.EqualFold(natRule.Protocol, "any") {
+				dnat = rule
+			}
+		}
+	}
+	return snat, dnat
+}
+
+// findNatRule returns the rule of the given type with the given id, or the
+// first rule of the type accepted by legacy. Resources created by earlier
+// versions of the provider have no rule id, legacy finds their rule by its
+// content.
+func findNatRule(rules []*types.NatRule, ruleType, id string, legacy func(*types.GatewayNatRule) bool) *types.NatRule {
+	for _, rule := range rules {
+		if rule.RuleType == ruleType && rule.ID == id && rule.GatewayNatRule != nil {
+			return rule
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.RuleType == ruleType && rule.GatewayNatRule != nil && legacy(rule.GatewayNatRule) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// natInterface returns the reference of the edge gateway interface on the
+// network with the given name, the uplink interface when no name is given
+func natInterface(edgeGateway *types.EdgeGateway, networkName string) (*types.Reference, error) {
+	if networkName != "" {
+		gatewayInterface := findGatewayInterface(edgeGateway, networkName)
+		if gatewayInterface == nil {
+			return nil, fmt.Errorf("The edge gateway '%s' has no interface on the network '%s'", edgeGateway.Name, networkName)
+		}
+		return &types.Reference{HREF: gatewayInterface.Network.HREF}, nil
+	}
+
+	if edgeGateway.Configuration != nil && edgeGateway.Configuration.GatewayInterfaces != nil {
+		for _, gatewayInterface := range edgeGateway.Configuration.GatewayInterfaces.GatewayInterface {
+			if gatewayInterface.InterfaceType == "uplink" && gatewayInterface.Network != nil {
+				return &types.Reference{HREF: gatewayInterface.Network.HREF}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("The edge gateway '%s' has no uplink interface", edgeGateway.Name)
+}
+
+// natInterfaceName returns the name of the network of the interface of a rule
+func natInterfaceName(edgeGateway *types.EdgeGateway, natRule *types.GatewayNatRule) string {
+	if natRule.Interface == nil {
+		return ""
+	}
+	if gatewayInterface := findGatewayInterfaceByHREF(edgeGateway, natRule.Interface.HREF); gatewayInterface != nil {
+		return gatewayInterface.Network.Name
+	}
+	return natRule.Interface.Name
+}
+
+// setNatRule replaces the rule with the given id with the given rule, the rule
+// is added at the end when there is none and the rule is removed when the
+// given rule is nil. The position of the given rule in the result is returned.
+func setNatRule(rules []*types.NatRule, id string, rule *types.NatRule) ([]*types.NatRule, int) {
+	updated := make([]*types.NatRule, 0, len(rules)+1)
+	position := -1
+	for _, existing := range rules {
+		if id != "" && existing.ID == id {
+			if rule != nil && position == -1 {
+				position = len(updated)
+				updated = append(updated, rule)
+			}
+			continue
+		}
+		updated = append(updated, existing)
+	}
+
+	if rule != nil && position == -1 {
+		position = len(updated)
+		updated = append(updated, rule)
+	}
+
+	return updated, position
+}
+
+// changeNatRule adds, updates or removes the NAT rule with the id of the
+// resource, the other rules of the edge gateway are sent back as they are.
+// The rule is removed when expand is nil. The id of a new rule is set once
+// vCD has assigned it.
+func changeNatRule(d *schema.ResourceData, meta interface{}, expand func(*types.EdgeGateway) (*types.NatRule, error)) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	var rule *types.NatRule
+	if expand == nil {
+		found := false
+		for _, existing := range edgeGatewayNatRules(edgeGateway.EdgeGateway) {
+			found = found || existing.ID == d.Id()
+		}
+		if !found {
+			log.Printf("[DEBUG] NAT rule %s is already removed", d.Id())
+			return nil
+		}
+	} else {
+		rule, err = expand(edgeGateway.EdgeGateway)
+		if err != nil {
+			return err
+		}
+		rule.ID = d.Id()
+	}
+
+	log.Printf("[INFO] Changing NAT rule %s of edge gateway %s", d.Id(), edgeGateway.EdgeGateway.Name)
+
+	position := 0
+	ctx, cancel := vcdClient.changeContext(d, expand == nil)
+	defer cancel()
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		// The rules are read again as the edge gateway may have changed
+		// while it was busy
+		err := edgeGateway.Refresh()
+		if err != nil {
+			return govcd.Task{}, errors.Wrapf(err, "cannot read edge gateway: %s", edgeGateway.EdgeGateway.Name)
+		}
+
+		natService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService
+		if natService == nil {
+			natService = &types.NatService{IsEnabled: true}
+		}
+		natService.NatRule, position = setNatRule(natService.NatRule, d.Id(), rule)
+
+		return configureEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF, &edgeGatewayServices{
+			NatService: natService,
+		})
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot change NAT rule: %s", d.Id())
+	}
+
+	if rule == nil {
+		return nil
+	}
+
+	// vCD assigns the id of a new rule, it is found by its position
+	err = edgeGateway.Refresh()
+	if err != nil {
+		return errors.Wrapf(err, "cannot read edge gateway: %s", edgeGateway.EdgeGateway.Name)
+	}
+
+	rules := edgeGatewayNatRules(edgeGateway.EdgeGateway)
+	if position >= len(rules) {
+		return fmt.Errorf("Unable to find NAT rule on edge gateway %s", edgeGateway.EdgeGateway.Name)
+	}
+	d.SetId(rules[position].ID)
+
+	return nil
+}
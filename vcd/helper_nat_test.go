@@ -0,0 +1,86 @@
+package vcd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func TestFind1to1NatRules(t *testing.T) {
+	rules := testReadNatRules(t)
+
+	snat, dnat := find1to1NatRules(rules, "10.0.0.10", "192.168.1.10")
+	if snat == nil || snat.ID != "65537" {
+		t.Errorf("expected SNAT rule 65537, got %#v", snat)
+	}
+	if dnat == nil || dnat.ID != "65539" {
+		t.Errorf("expected the DNAT rule of any port 65539, got %#v", dnat)
+	}
+
+	snat, dnat = find1to1NatRules(rules[1:], "10.0.0.10", "192.168.1.10")
+	if snat != nil || dnat == nil {
+		t.Errorf("expected only the DNAT rule, got %#v and %#v", snat, dnat)
+	}
+}
+
+func TestFindNatRule(t *testing.T) {
+	rules := testReadNatRules(t)
+
+	if rule := findNatRule(rules, "DNAT", "65538", func(*types.GatewayNatRule) bool { return false }); rule == nil || rule.ID != "65538" {
+		t.Errorf("expected DNAT rule 65538, got %#v", rule)
+	}
+	if rule := findNatRule(rules, "SNAT", "65538", func(*types.GatewayNatRule) bool { return false }); rule != nil {
+		t.Errorf("expected no SNAT rule with the id of a DNAT rule, got %#v", rule)
+	}
+
+	rule := findNatRule(rules, "DNAT", "192.168.1.10:443 > 10.0.0.10:443", func(r *types.GatewayNatRule) bool {
+		return r.OriginalIP == "192.168.1.10" && r.OriginalPort == "443"
+	})
+	if rule == nil || rule.ID != "65538" {
+		t.Errorf("expected the legacy DNAT rule 65538, got %#v", rule)
+	}
+}
+
+func TestSetNatRule(t *testing.T) {
+	rules := testReadNatRules(t)
+
+	cases := []struct {
+		name             string
+		id               string
+		rule             *types.NatRule
+		expected         []string
+		expectedPosition int
+	}{
+		{"add", "", &types.NatRule{ID: "new"}, []string{"65537", "65538", "65539", "new"}, 3},
+		{"update", "65538", &types.NatRule{ID: "updated"}, []string{"65537", "updated", "65539"}, 1},
+		{"remove", "65537", nil, []string{"65538", "65539"}, -1},
+	}
+
+	for _, c := range cases {
+		updated, position := setNatRule(rules, c.id, c.rule)
+		ids := make([]string, 0, len(updated))
+		for _, rule := range updated {
+			ids = append(ids, rule.ID)
+		}
+		if !reflect.DeepEqual(ids, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, ids)
+		}
+		if position != c.expectedPosition {
+			t.Errorf("%s: expected position %d, got %d", c.name, c.expectedPosition, position)
+		}
+	}
+}
+
+func TestValidateNatPort(t *testing.T) {
+	for _, port := range []string{"any", "80", "8000-8080"} {
+		if _, errs := validateNatPort(port, "port"); len(errs) != 0 {
+			t.Errorf("expected %s to be valid, got %v", port, errs)
+		}
+	}
+	for _, port := range []string{"", "Any", "80-", "http"} {
+		if _, errs := validateNatPort(port, "port"); len(errs) == 0 {
+			t.Errorf("expected %s to be invalid", port)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package vcd
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+const mimeOrgVdcNetwork = "application/vnd.vmware.vcloud.orgVdcNetwork+xml"
+
+// updateOrgVDCNetwork sends the network back to vCD. The network is edited
+// through its edit link, which is the admin view of the network.
+func updateOrgVDCNetwork(client *govcd.Client, network *types.OrgVDCNetwork) (govcd.Task, error) {
+	href := strings.Replace(network.HREF, "/api/network/", "/api/admin/network/", 1)
+	for _, link := range network.Link {
+		if link.Rel == "edit" && link.Type == mimeOrgVdcNetwork {
+			href = link.HREF
+			break
+		}
+	}
+
+	update := *network
+	update.Xmlns = types.XMLNamespaceXMLNS
+	update.Link = nil
+	update.Tasks = nil
+
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, http.MethodPut, href, mimeOrgVdcNetwork, &update, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
+
+// expandNetworkUpdate applies the attributes of vcd_network which can be
+// changed in place to the network read from vCD
+func expandNetworkUpdate(d *schema.ResourceData, network *types.OrgVDCNetwork) error {
+	network.IsShared = d.Get("shared").(bool)
+
+	// A bridged network inherits the IP settings of its parent
+	if network.Configuration != nil && network.Configuration.FenceMode == types.FenceModeBridged {
+		return nil
+	}
+
+	if network.Configuration == nil || network.Configuration.IPScopes == nil || len(network.Configuration.IPScopes.IPScope) == 0 {
+		return errors.Errorf("the network %s has no IP scope", network.Name)
+	}
+
+	ipRanges := expandIPRange(d.Get("static_ip_pool").(*schema.Set).List())
+
+	ipScope := network.Configuration.IPScopes.IPScope[0]
+	ipScope.DNS1 = d.Get("dns1").(string)
+	ipScope.DNS2 = d.Get("dns2").(string)
+	ipScope.DNSSuffix = d.Get("dns_suffix").(string)
+	ipScope.IPRanges = &ipRanges
+	// The allocations are read-only
+	ipScope.AllocatedIPAddresses = nil
+	ipScope.SubAllocations = nil
+
+	return nil
+}
+
+// networkFenceModeArguments lists, per fence mode, the arguments of
+// vcd_network a network needs and the arguments it cannot have
+var networkFenceModeArguments = map[string]struct {
+	required  []string
+	conflicts []string
+}{
+	types.FenceModeNAT: {
+		required:  []string{"edge_gateway", "gateway"},
+		conflicts: []string{"parent_network"},
+	},
+	types.FenceModeIsolated: {
+		required:  []string{"gateway"},
+		conflicts: []string{"edge_gateway", "parent_network"},
+	},
+	// The IP settings of a bridged network are inherited from its parent
+	types.FenceModeBridged: {
+		required:  []string{"parent_network"},
+		conflicts: []string{"edge_gateway", "gateway", "dns_suffix", "static_ip_pool", "dhcp_pool"},
+	},
+}
+
+// validateNetworkArguments checks the arguments set for a network against its
+// fence mode
+func validateNetworkArguments(fenceMode string, isSet func(string) bool) error {
+	arguments, ok := networkFenceModeArguments[fenceMode]
+	if !ok {
+		return errors.Errorf("unsupported fence mode: %s", fenceMode)
+	}
+
+	for _, key := range arguments.required {
+		if !isSet(key) {
+			return errors.Errorf("%s is required for a %s network", key, fenceMode)
+		}
+	}
+	for _, key := range arguments.conflicts {
+		if isSet(key) {
+			return errors.Errorf("%s cannot be set for a %s network", key, fenceMode)
+		}
+	}
+
+	return nil
+}
+
+// externalNetworkRecords is the result of an externalNetwork query, the
+// vendored query result has no external network records
+type externalNetworkRecords struct {
+	Record []*struct {
+		HREF string `xml:"href,attr"`
+		Name string `xml:"name,attr"`
+	} `xml:"NetworkRecord"`
+}
+
+// findExternalNetwork returns a reference to the external network with the
+// given name or HREF
+func findExternalNetwork(client *govcd.Client, network string) (*types.Reference, error) {
+	if isHREF(network) {
+		return &types.Reference{HREF: network}, nil
+	}
+
+	u := client.VCDEndpoint
+	u.Path += "/query"
+	u.RawQuery = url.Values{
+		"type":          []string{"externalNetwork"},
+		"format":        []string{"records"},
+		"filter":        []string{"name==" + url.QueryEscape(network)},
+		"filterEncoded": []string{"true"},
+	}.Encode()
+
+	records := &externalNetworkRecords{}
+	err := getXML(client, u.String(), records)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot find external network: %s", network)
+	}
+
+	for _, record := range records.Record {
+		if record.Name == network {
+			return &types.Reference{HREF: record.HREF, Name: record.Name}, nil
+		}
+	}
+	return nil, errors.Errorf("cannot find external network: %s", network)
+}
+
+// expandNetworkDhcpService returns the DHCP service of an isolated network,
+// the network serves the pools itself
+func expandNetworkDhcpService(configured []interface{}) *types.GatewayDhcpService {
+	dhcpService := &types.GatewayDhcpService{IsEnabled: len(configured) > 0}
+	for _, raw := range configured {
+		pool := raw.(map[string]interface{})
+		dhcpService.Pool = append(dhcpService.Pool, &types.DhcpPoolService{
+			IsEnabled:        true,
+			DefaultLeaseTime: pool["default_lease_time"].(int),
+			MaxLeaseTime:     pool["max_lease_time"].(int),
+			LowIPAddress:     pool["start_address"].(string),
+			HighIPAddress:    pool["end_address"].(string),
+		})
+	}
+	return dhcpService
+}
+
+// flattenNetworkDhcpPools returns the DHCP pools an isolated network serves
+func flattenNetworkDhcpPools(serviceConfig *types.GatewayFeatures) []interface{} {
+	dhcpPools := make([]interface{}, 0)
+	if serviceConfig == nil || serviceConfig.GatewayDhcpService == nil {
+		return dhcpPools
+	}
+
+	for _, pool := range serviceConfig.GatewayDhcpService.Pool {
+		dhcpPools = append(dhcpPools, map[string]interface{}{
+			"start_address":      pool.LowIPAddress,
+			"end_address":        pool.HighIPAddress,
+			"default_lease_time": pool.DefaultLeaseTime,
+			"max_lease_time":     pool.MaxLeaseTime,
+		})
+	}
+	return dhcpPools
+}
@@ -0,0 +1,142 @@
+package vcd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func TestExpandNetworkUpdate(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVcdNetwork().Schema, map[string]interface{}{
+		"name":         "net",
+		"edge_gateway": "edge",
+		"gateway":      "10.0.0.1",
+		"dns1":         "10.0.0.2",
+		"dns_suffix":   "example.com",
+		"shared":       true,
+		"static_ip_pool": []interface{}{
+			map[string]interface{}{
+				"start_address": "10.0.0.10",
+				"end_address":   "10.0.0.100",
+			},
+		},
+	})
+
+	network := &types.OrgVDCNetwork{
+		Name: "net",
+		Configuration: &types.NetworkConfiguration{
+			FenceMode: "natRouted",
+			IPScopes: &types.IPScopes{
+				IPScope: []*types.IPScope{{
+					Gateway: "10.0.0.1",
+					Netmask: "255.255.255.0",
+					DNS1:    "8.8.8.8",
+					IPRanges: &types.IPRanges{IPRange: []*types.IPRange{{
+						StartAddress: "10.0.0.10",
+						EndAddress:   "10.0.0.20",
+					}}},
+				}},
+			},
+		},
+	}
+
+	if err := expandNetworkUpdate(d, network); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &types.IPScope{
+		Gateway:   "10.0.0.1",
+		Netmask:   "255.255.255.0",
+		DNS1:      "10.0.0.2",
+		DNS2:      "8.8.4.4",
+		DNSSuffix: "example.com",
+		IPRanges: &types.IPRanges{IPRange: []*types.IPRange{{
+			StartAddress: "10.0.0.10",
+			EndAddress:   "10.0.0.100",
+		}}},
+	}
+	if ipScope := network.Configuration.IPScopes.IPScope[0]; !reflect.DeepEqual(ipScope, expected) {
+		t.Errorf("expected %#v, got %#v", expected, ipScope)
+	}
+	if !network.IsShared {
+		t.Errorf("expected the network to be shared")
+	}
+
+	if err := expandNetworkUpdate(d, &types.OrgVDCNetwork{Name: "isolated"}); err == nil {
+		t.Errorf("expected an error for a network without IP scope")
+	}
+}
+
+func TestValidateNetworkArguments(t *testing.T) {
+	cases := []struct {
+		fenceMode string
+		set       []string
+		valid     bool
+	}{
+		{"natRouted", []string{"edge_gateway", "gateway"}, true},
+		{"natRouted", []string{"gateway"}, false},
+		{"natRouted", []string{"edge_gateway", "gateway", "parent_network"}, false},
+		{"isolated", []string{"gateway", "dhcp_pool"}, true},
+		{"isolated", []string{"edge_gateway", "gateway"}, false},
+		{"bridged", []string{"parent_network"}, true},
+		{"bridged", []string{"parent_network", "static_ip_pool"}, false},
+		{"bridged", nil, false},
+		{"routed", []string{"gateway"}, false},
+	}
+
+	for _, c := range cases {
+		err := validateNetworkArguments(c.fenceMode, func(key string) bool {
+			return isStringMember(c.set, key)
+		})
+		if (err == nil) != c.valid {
+			t.Errorf("%s %v: expected valid=%t, got %v", c.fenceMode, c.set, c.valid, err)
+		}
+	}
+}
+
+func TestNetworkDhcpPools(t *testing.T) {
+	pools := []interface{}{
+		map[string]interface{}{
+			"start_address":      "10.0.0.2",
+			"end_address":        "10.0.0.100",
+			"default_lease_time": 3600,
+			"max_lease_time":     7200,
+		},
+	}
+
+	dhcpService := expandNetworkDhcpService(pools)
+	if !dhcpService.IsEnabled || len(dhcpService.Pool) != 1 || dhcpService.Pool[0].Network != nil {
+		t.Errorf("expected an enabled service with a single pool, got %#v", dhcpService)
+	}
+
+	flattened := flattenNetworkDhcpPools(&types.GatewayFeatures{GatewayDhcpService: dhcpService})
+	if !reflect.DeepEqual(flattened, pools) {
+		t.Errorf("expected %#v, got %#v", pools, flattened)
+	}
+
+	if flattened := flattenNetworkDhcpPools(nil); len(flattened) != 0 {
+		t.Errorf("expected no pools, got %#v", flattened)
+	}
+}
+
+func TestExpandNetworkUpdateBridged(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVcdNetwork().Schema, map[string]interface{}{
+		"name":           "net",
+		"fence_mode":     "bridged",
+		"parent_network": "external",
+		"shared":         true,
+	})
+
+	network := &types.OrgVDCNetwork{
+		Name:          "net",
+		Configuration: &types.NetworkConfiguration{FenceMode: "bridged"},
+	}
+	if err := expandNetworkUpdate(d, network); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !network.IsShared || network.Configuration.IPScopes != nil {
+		t.Errorf("expected only the sharing to change, got %#v", network)
+	}
+}
@@ -0,0 +1,307 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+const mimeProductSections = "application/vnd.vmware.vcloud.productSections+xml"
+
+// productSectionList is the product sections of a vApp or a VM, the vendored
+// ProductSectionList has a single section and drops the property attributes
+// which have to be sent back on an update
+type productSectionList struct {
+	ProductSection []*productSection `xml:"ProductSection"`
+}
+
+type productSection struct {
+	Attrs       []xml.Attr         `xml:",any,attr"`
+	Info        string             `xml:"Info"`
+	Product     string             `xml:"Product"`
+	Vendor      string             `xml:"Vendor"`
+	Version     string             `xml:"Version"`
+	FullVersion string             `xml:"FullVersion"`
+	Property    []*productProperty `xml:"Property"`
+}
+
+type productProperty struct {
+	Attrs       []xml.Attr              `xml:",any,attr"`
+	Label       string                  `xml:"Label"`
+	Description string                  `xml:"Description"`
+	Value       []*productPropertyValue `xml:"Value"`
+}
+
+type productPropertyValue struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// ovfProductSectionList is the write counterpart of productSectionList, the
+// namespace prefixes are spelled out like in the vendored OVF types
+type ovfProductSectionList struct {
+	XMLName        xml.Name             `xml:"ProductSectionList"`
+	Xmlns          string               `xml:"xmlns,attr"`
+	Ovf            string               `xml:"xmlns:ovf,attr"`
+	Vcloud         string               `xml:"xmlns:vcloud,attr"`
+	Xsi            string               `xml:"xmlns:xsi,attr"`
+	ProductSection []*ovfProductSection `xml:"ovf:ProductSection"`
+}
+
+type ovfProductSection struct {
+	Attrs       []xml.Attr     `xml:",any,attr"`
+	Info        string         `xml:"ovf:Info"`
+	Product     string         `xml:"ovf:Product,omitempty"`
+	Vendor      string         `xml:"ovf:Vendor,omitempty"`
+	Version     string         `xml:"ovf:Version,omitempty"`
+	FullVersion string         `xml:"ovf:FullVersion,omitempty"`
+	Property    []*ovfProperty `xml:"ovf:Property,omitempty"`
+}
+
+type ovfProperty struct {
+	Attrs       []xml.Attr          `xml:",any,attr"`
+	Label       string              `xml:"ovf:Label,omitempty"`
+	Description string              `xml:"ovf:Description,omitempty"`
+	Value       []*ovfPropertyValue `xml:"ovf:Value,omitempty"`
+}
+
+type ovfPropertyValue struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// ovfAttr returns the value of an OVF attribute
+func ovfAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name && (attr.Name.Space == types.XMLNamespaceOVF || attr.Name.Space == "") {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// prefixedAttrs converts decoded attributes to attributes with the prefixes
+// declared on the product section list, namespace declarations are dropped
+func prefixedAttrs(attrs []xml.Attr) []xml.Attr {
+	prefixes := map[string]string{
+		types.XMLNamespaceOVF:    "ovf:",
+		types.XMLNamespaceVCloud: "vcloud:",
+		types.XMLNamespaceXSI:    "xsi:",
+	}
+
+	converted := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+
+		if prefix, ok := prefixes[attr.Name.Space]; ok {
+			attr.Name = xml.Name{Local: prefix + attr.Name.Local}
+		}
+		converted = append(converted, attr)
+	}
+	return converted
+}
+
+func ovfValueAttrs(value string) []xml.Attr {
+	return []xml.Attr{{Name: xml.Name{Local: "ovf:value"}, Value: value}}
+}
+
+// newOvfProperty builds a user configurable string property
+func newOvfProperty(key, value string) *ovfProperty {
+	return &ovfProperty{
+		Attrs: []xml.Attr{
+			{Name: xml.Name{Local: "ovf:key"}, Value: key},
+			{Name: xml.Name{Local: "ovf:type"}, Value: "string"},
+			{Name: xml.Name{Local: "ovf:userConfigurable"}, Value: "true"},
+			{Name: xml.Name{Local: "ovf:value"}, Value: value},
+		},
+		Label: key,
+		Value: []*ovfPropertyValue{{Attrs: ovfValueAttrs(value)}},
+	}
+}
+
+// expandOvfProperties converts a key to value map to a product section of
+// user configurable string properties
+func expandOvfProperties(properties map[string]interface{}) *ovfProductSection {
+	section := &ovfProductSection{
+		Info: "Custom properties",
+	}
+
+	for _, key := range sortedKeys(properties) {
+		section.Property = append(section.Property, newOvfProperty(key, properties[key].(string)))
+	}
+
+	return section
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// value returns the current value of the property, the value attribute of
+// the property itself is the default only
+func (p *productProperty) value() string {
+	for _, value := range p.Value {
+		if ovfAttr(value.Attrs, "configuration") == "" {
+			return ovfAttr(value.Attrs, "value")
+		}
+	}
+	return ovfAttr(p.Attrs, "value")
+}
+
+// flattenOvfProperties returns the values of the properties of the given
+// keys, a property which no longer exists is left out
+func flattenOvfProperties(sections *productSectionList, keys []string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for _, section := range sections.ProductSection {
+		for _, property := range section.Property {
+			key := ovfAttr(property.Attrs, "key")
+			if key != "" && isStringMember(keys, key) {
+				properties[key] = property.value()
+			}
+		}
+	}
+	return properties
+}
+
+// planProductSections updates the product sections to the desired values.
+// The properties removed from the configuration are deleted, the ones which
+// do not exist yet are added to the last section, other properties are kept.
+func planProductSections(sections *productSectionList, removed []string, desired map[string]interface{}) *ovfProductSectionList {
+	planned := &ovfProductSectionList{
+		Xmlns:  types.XMLNamespaceXMLNS,
+		Ovf:    types.XMLNamespaceOVF,
+		Vcloud: types.XMLNamespaceVCloud,
+		Xsi:    types.XMLNamespaceXSI,
+	}
+
+	found := make(map[string]bool, len(desired))
+	for _, section := range sections.ProductSection {
+		plannedSection := &ovfProductSection{
+			Attrs:       prefixedAttrs(section.Attrs),
+			Info:        section.Info,
+			Product:     section.Product,
+			Vendor:      section.Vendor,
+			Version:     section.Version,
+			FullVersion: section.FullVersion,
+		}
+
+		for _, property := range section.Property {
+			key := ovfAttr(property.Attrs, "key")
+			if isStringMember(removed, key) {
+				continue
+			}
+
+			plannedProperty := &ovfProperty{
+				Attrs:       prefixedAttrs(property.Attrs),
+				Label:       property.Label,
+				Description: property.Description,
+			}
+			for _, value := range property.Value {
+				plannedProperty.Value = append(plannedProperty.Value, &ovfPropertyValue{Attrs: prefixedAttrs(value.Attrs)})
+			}
+
+			if value, ok := desired[key]; ok && !found[key] {
+				found[key] = true
+				plannedProperty.Value = []*ovfPropertyValue{{Attrs: ovfValueAttrs(value.(string))}}
+			}
+
+			plannedSection.Property = append(plannedSection.Property, plannedProperty)
+		}
+
+		planned.ProductSection = append(planned.ProductSection, plannedSection)
+	}
+
+	if len(planned.ProductSection) == 0 {
+		planned.ProductSection = append(planned.ProductSection, &ovfProductSection{Info: "Custom properties"})
+	}
+
+	last := planned.ProductSection[len(planned.ProductSection)-1]
+	for _, key := range sortedKeys(desired) {
+		if !found[key] {
+			last.Property = append(last.Property, newOvfProperty(key, desired[key].(string)))
+		}
+	}
+
+	return planned
+}
+
+func readProductSections(client *govcd.Client, href string) (*productSectionList, error) {
+	sections := &productSectionList{}
+	err := getXML(client, href+"/productSections", sections)
+	if err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+func updateProductSections(client *govcd.Client, href string, sections *ovfProductSectionList) (govcd.Task, error) {
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, "PUT", href+"/productSections", mimeProductSections, sections, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
+
+// configureOvfProperties writes the changes of the ovf_properties attribute
+// to the product sections of the vApp or the VM at href
+func configureOvfProperties(d *schema.ResourceData, href string, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	if !d.HasChange("ovf_properties") {
+		return nil
+	}
+
+	oldValue, newValue := d.GetChange("ovf_properties")
+	desired := newValue.(map[string]interface{})
+	removed := make([]string, 0)
+	for key := range oldValue.(map[string]interface{}) {
+		if _, ok := desired[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	log.Printf("[TRACE] Changing OVF properties of %s", href)
+
+	sections, err := readProductSections(&vcdClient.Client, href)
+	if err != nil {
+		return err
+	}
+
+	planned := planProductSections(sections, removed, desired)
+
+	ctx, cancel := vcdClient.changeContext(d, false)
+	defer cancel()
+
+	return retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return updateProductSections(&vcdClient.Client, href, planned)
+	})
+}
+
+// readOvfProperties sets the ovf_properties attribute, only the properties
+// already managed are read as the product sections of a template usually
+// hold many more
+func readOvfProperties(d *schema.ResourceData, href string, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	keys := sortedKeys(d.Get("ovf_properties").(map[string]interface{}))
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sections, err := readProductSections(&vcdClient.Client, href)
+	if err != nil {
+		return err
+	}
+
+	return d.Set("ovf_properties", flattenOvfProperties(sections, keys))
+}
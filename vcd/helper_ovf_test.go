@@ -0,0 +1,71 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenOvfProperties(t *testing.T) {
+	sections := testReadProductSections(t)
+
+	properties := flattenOvfProperties(sections, []string{"hostname", "dns", "missing"})
+	expected := map[string]interface{}{
+		"hostname": "appliance-1",
+		"dns":      "8.8.8.8",
+	}
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected %#v, got %#v", expected, properties)
+	}
+}
+
+func TestPlanProductSections(t *testing.T) {
+	sections := testReadProductSections(t)
+
+	planned := planProductSections(sections, []string{"password"}, map[string]interface{}{
+		"hostname": "appliance-2",
+		"ntp":      "pool.ntp.org",
+	})
+
+	output, err := xml.Marshal(planned)
+	if err != nil {
+		t.Fatalf("cannot marshal product sections: %v", err)
+	}
+
+	// The planned sections are read back as they are sent
+	sent := &productSectionList{}
+	if err := xml.Unmarshal(output, sent); err != nil {
+		t.Fatalf("cannot decode planned product sections: %v", err)
+	}
+
+	properties := flattenOvfProperties(sent, []string{"hostname", "dns", "ntp", "password"})
+	expected := map[string]interface{}{
+		"hostname": "appliance-2",
+		"dns":      "8.8.8.8",
+		"ntp":      "pool.ntp.org",
+	}
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected %#v, got %#v", expected, properties)
+	}
+
+	for _, fragment := range []string{
+		`<ovf:ProductSection ovf:required="false">`,
+		`<ovf:Product>Appliance</ovf:Product>`,
+		`<ovf:Label>Hostname</ovf:Label>`,
+	} {
+		if !strings.Contains(string(output), fragment) {
+			t.Errorf("expected %s to be kept in %s", fragment, output)
+		}
+	}
+}
+
+func TestPlanProductSectionsWithoutSections(t *testing.T) {
+	planned := planProductSections(&productSectionList{}, nil, map[string]interface{}{
+		"hostname": "appliance-1",
+	})
+
+	if len(planned.ProductSection) != 1 || len(planned.ProductSection[0].Property) != 1 {
+		t.Fatalf("expected a single section with a single property, got %#v", planned.ProductSection)
+	}
+}
@@ -0,0 +1,161 @@
+package vcd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// Maximum page size accepted by the query service
+const queryPageSize = 128
+
+// queryDataSource describes a list data source backed by the query service,
+// every record of the result is exported as an element of a list attribute
+type queryDataSource struct {
+	// The query type, e.g. vm or orgVdcNetwork
+	queryType string
+
+	// The name of the list attribute holding the records
+	attribute string
+
+	// A filter which is always applied on top of the user given one
+	filter string
+
+	// The attributes of a record, all of them are computed
+	record map[string]schema.ValueType
+
+	// Converts the records of a result to attribute maps
+	flatten func(*types.QueryResultRecordsType) []map[string]interface{}
+}
+
+func (q *queryDataSource) Resource() *schema.Resource {
+	record := make(map[string]*schema.Schema, len(q.record))
+	for name, valueType := range q.record {
+		record[name] = &schema.Schema{
+			Type:     valueType,
+			Computed: true,
+		}
+	}
+
+	return &schema.Resource{
+		Read: q.Read,
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(false),
+			"vdc": vdcSchema(false),
+			"filter": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			q.attribute: {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: record,
+				},
+			},
+		},
+	}
+}
+
+func (q *queryDataSource) Read(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	filter := combineFilters(q.filter, d.Get("filter").(string))
+
+	// The records of the whole org are listed unless the VDC is overridden,
+	// the VDC of the provider is not looked up in another org
+	orgName, _ := d.Get("org").(string)
+	vdcName, _ := d.Get("vdc").(string)
+	if orgName != "" && vdcName == "" {
+		return errors.Errorf("vdc is required when org is set: org=%s", orgName)
+	}
+	if vdcName != "" {
+		vdc, err := vcdClient.findVdc(orgName, vdcName)
+		if err != nil {
+			return err
+		}
+		filter = combineFilters(filter, "vdc=="+vdc.Vdc.HREF)
+	}
+
+	results, err := queryAllRecords(vcdClient, q.queryType, filter)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s-%d", q.queryType, hashcode.String(filter)))
+	d.Set(q.attribute, q.flatten(results))
+
+	return nil
+}
+
+// queryAllRecords runs a records query and follows the result pages until
+// all the matching records are read
+func queryAllRecords(vcdClient *VCDClient, queryType, filter string) (*types.QueryResultRecordsType, error) {
+	all := &types.QueryResultRecordsType{}
+
+	for page := 1; ; page++ {
+		queryParams := map[string]string{
+			"type":     queryType,
+			"format":   "records",
+			"page":     strconv.Itoa(page),
+			"pageSize": strconv.Itoa(queryPageSize),
+		}
+		if filter != "" {
+			queryParams["filter"] = filter
+			queryParams["filterEncoded"] = "true"
+		}
+
+		query, err := vcdClient.Query(queryParams)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot execute query: %v", queryParams)
+		}
+
+		read := appendQueryResults(all, query.Results)
+		all.Total = query.Results.Total
+
+		if read == 0 || page*queryPageSize >= int(query.Results.Total) {
+			return all, nil
+		}
+	}
+}
+
+// appendQueryResults appends the records of src to dst and returns the number
+// of records appended
+func appendQueryResults(dst, src *types.QueryResultRecordsType) int {
+	read := len(src.EdgeGatewayRecord) + len(src.VMRecord) + len(src.VAppRecord) +
+		len(src.OrgVdcRecord) + len(src.OrgVdcNetworkRecord) + len(src.CatalogRecord) +
+		len(src.VAppTemplateRecord) + len(src.OrgVdcStorageProfileRecord)
+
+	dst.EdgeGatewayRecord = append(dst.EdgeGatewayRecord, src.EdgeGatewayRecord...)
+	dst.VMRecord = append(dst.VMRecord, src.VMRecord...)
+	dst.VAppRecord = append(dst.VAppRecord, src.VAppRecord...)
+	dst.OrgVdcRecord = append(dst.OrgVdcRecord, src.OrgVdcRecord...)
+	dst.OrgVdcNetworkRecord = append(dst.OrgVdcNetworkRecord, src.OrgVdcNetworkRecord...)
+	dst.CatalogRecord = append(dst.CatalogRecord, src.CatalogRecord...)
+	dst.VAppTemplateRecord = append(dst.VAppTemplateRecord, src.VAppTemplateRecord...)
+	dst.OrgVdcStorageProfileRecord = append(dst.OrgVdcStorageProfileRecord, src.OrgVdcStorageProfileRecord...)
+
+	return read
+}
+
+// combineFilters joins FIQL filters with a logical AND, empty filters are
+// skipped
+func combineFilters(filters ...string) string {
+	parts := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		if filter != "" {
+			parts = append(parts, "("+filter+")")
+		}
+	}
+
+	if len(parts) == 1 {
+		return strings.TrimSuffix(strings.TrimPrefix(parts[0], "("), ")")
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,66 @@
+package vcd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func TestCombineFilters(t *testing.T) {
+	cases := []struct {
+		filters  []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"", ""}, ""},
+		{[]string{"name==web*"}, "name==web*"},
+		{[]string{"", "name==web*"}, "name==web*"},
+		{[]string{"isVAppTemplate==false", "name==web*,name==db*"}, "(isVAppTemplate==false);(name==web*,name==db*)"},
+	}
+
+	for _, c := range cases {
+		if actual := combineFilters(c.filters...); actual != c.expected {
+			t.Errorf("expected %q for %#v, got %q", c.expected, c.filters, actual)
+		}
+	}
+}
+
+func TestAppendQueryResults(t *testing.T) {
+	all := &types.QueryResultRecordsType{}
+
+	read := appendQueryResults(all, &types.QueryResultRecordsType{
+		VMRecord: []*types.QueryResultVMRecordType{{Name: "web1"}, {Name: "web2"}},
+	})
+	if read != 2 {
+		t.Errorf("expected 2 records to be read, got %d", read)
+	}
+
+	read = appendQueryResults(all, &types.QueryResultRecordsType{
+		VMRecord: []*types.QueryResultVMRecordType{{Name: "web3"}},
+	})
+	if read != 1 {
+		t.Errorf("expected 1 record to be read, got %d", read)
+	}
+
+	if len(all.VMRecord) != 3 || all.VMRecord[2].Name != "web3" {
+		t.Errorf("expected the records of both pages, got %#v", all.VMRecord)
+	}
+
+	if read = appendQueryResults(all, &types.QueryResultRecordsType{}); read != 0 {
+		t.Errorf("expected no records to be read, got %d", read)
+	}
+}
+
+func TestQueryDataSourceOrgWithoutVdc(t *testing.T) {
+	q := &queryDataSource{queryType: "vm", attribute: "vms"}
+
+	d := q.Resource().TestResourceData()
+	d.Set("org", "other")
+
+	// The VDC of the provider is not looked up in another org
+	err := q.Read(d, &VCDClient{})
+	if err == nil || !strings.Contains(err.Error(), "vdc is required") {
+		t.Errorf("expected vdc to be required with org, got %v", err)
+	}
+}
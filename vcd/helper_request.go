@@ -0,0 +1,73 @@
+package vcd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// getXML reads the entity at href and decodes it into out
+func getXML(client *govcd.Client, href string, out interface{}) error {
+	return doXMLRequest(client, http.MethodGet, href, "", nil, out)
+}
+
+// doXMLRequest sends in as an XML payload of the given content type and
+// decodes the response into out, both in and out are optional. A vCD error
+// response is returned as a *types.Error so it can be handled by the retry
+// helpers.
+func doXMLRequest(client *govcd.Client, method, href, contentType string, in, out interface{}) error {
+	u, err := url.ParseRequestURI(href)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse url: %s", href)
+	}
+
+	var body io.Reader
+	if in != nil {
+		payload, err := xml.Marshal(in)
+		if err != nil {
+			return errors.Wrapf(err, "cannot marshal xml payload")
+		}
+		body = bytes.NewBufferString(xml.Header + string(payload))
+	}
+
+	req := client.NewRequest(nil, method, *u, body)
+	if contentType != "" {
+		req.Header.Add("Content-Type", contentType)
+	}
+
+	resp, err := client.Http.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "cannot execute request: %s %s", method, href)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read response: %s %s", method, href)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		vcdError := &types.Error{}
+		if err := xml.Unmarshal(data, vcdError); err != nil || vcdError.MajorErrorCode == 0 {
+			return errors.Errorf("unexpected API response: %s %s: %s", method, href, resp.Status)
+		}
+		return vcdError
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	if err := xml.Unmarshal(data, out); err != nil {
+		return errors.Wrapf(err, "cannot decode response: %s %s", method, href)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package vcd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// The requests are sent again a few times when vCD or a proxy in front of it
+// is unavailable. The interval grows up to retryMaxInterval, which also
+// bounds the delay asked for by a Retry-After header.
+const (
+	retryMaxAttempts = 5
+	retryInterval    = 1 * time.Second
+	retryMaxInterval = 30 * time.Second
+)
+
+// retryTransport sends again the idempotent requests which vCD answered with
+// a throttling or an unavailability status
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A POST is never sent again here. It is only retried by the caller when
+	// it was throttled, vCD has not processed it then, any other failed POST
+	// may have been processed.
+	if !isIdempotentMethod(req.Method) {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		return vcdErrorResponse(resp, err)
+	}
+
+	// A request with a body which cannot be read again is sent once
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return vcdErrorResponse(t.base.RoundTrip(req))
+	}
+
+	interval := retryInterval
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !isUnavailableStatus(resp.StatusCode) || attempt == retryMaxAttempts {
+			return vcdErrorResponse(resp, err)
+		}
+
+		wait := retryAfter(resp, interval)
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		log.Printf("[DEBUG] %s %s: %s, retrying in %s", req.Method, req.URL, resp.Status, wait)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		interval *= 2
+		if interval > retryMaxInterval {
+			interval = retryMaxInterval
+		}
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			retry.Body, err = req.GetBody()
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot replay request: %s %s", req.Method, req.URL)
+			}
+		}
+		req = retry
+	}
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay asked for by the Retry-After header of the
+// response, in seconds or as a date, or interval when there is none
+func retryAfter(resp *http.Response, interval time.Duration) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return interval
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		wait = time.Until(date)
+	} else {
+		return interval
+	}
+
+	switch {
+	case wait < 0:
+		return 0
+	case wait > retryMaxInterval:
+		return retryMaxInterval
+	}
+	return wait
+}
+
+// vcdErrorResponse turns the 429 and 502 responses, which the vendored
+// client reports as unhandled, into a 503 response
+// carrying a vCD error. The major code of the error is the original status
+// unless vCD sent an error of its own, so that the response is classified as
+// throttled or unavailable.
+func vcdErrorResponse(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusBadGateway) {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read response: %s", resp.Status)
+	}
+
+	vcdError := &types.Error{}
+	if xml.Unmarshal(body, vcdError) != nil || vcdError.MajorErrorCode == 0 {
+		vcdError = &types.Error{
+			Message:        resp.Status,
+			MajorErrorCode: resp.StatusCode,
+			MinorErrorCode: "BAD_GATEWAY",
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			vcdError.MinorErrorCode = "TOO_MANY_REQUESTS"
+		}
+
+		body, err = xml.Marshal(vcdError)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.StatusCode = http.StatusServiceUnavailable
+	resp.Status = "503 Service Unavailable"
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(body))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
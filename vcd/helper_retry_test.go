@@ -0,0 +1,111 @@
+package vcd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	govcd "github.com/kublr/govcloudair"
+)
+
+// testRetryServer answers with the given status until it has been sent the
+// given number of requests, then echoes the body of the requests
+type testRetryServer struct {
+	status   int
+	failures int
+	requests int
+}
+
+func (s *testRetryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	if s.requests <= s.failures {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(s.status)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Write(body)
+}
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		method   string
+		status   int
+		failures int
+		requests int
+		// The status of the response returned by the transport
+		returned int
+	}{
+		{method: http.MethodPut, status: http.StatusTooManyRequests, failures: 2, requests: 3, returned: http.StatusOK},
+		{method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 1, requests: 2, returned: http.StatusOK},
+		{method: http.MethodGet, status: http.StatusBadGateway, failures: retryMaxAttempts, requests: retryMaxAttempts, returned: http.StatusServiceUnavailable},
+		{method: http.MethodPost, status: http.StatusTooManyRequests, failures: 1, requests: 1, returned: http.StatusServiceUnavailable},
+		{method: http.MethodPost, status: http.StatusBadGateway, failures: 1, requests: 1, returned: http.StatusBadGateway},
+		{method: http.MethodGet, status: http.StatusInternalServerError, failures: 1, requests: 1, returned: http.StatusInternalServerError},
+	}
+
+	for _, c := range cases {
+		server := &testRetryServer{status: c.status, failures: c.failures}
+		ts := httptest.NewServer(server)
+
+		client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport}}
+		req, _ := http.NewRequest(c.method, ts.URL+"/api/vApp/vapp-1", strings.NewReader("body"))
+		resp, err := client.Do(req)
+		ts.Close()
+		if err != nil {
+			t.Fatalf("%s %d: %s", c.method, c.status, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if server.requests != c.requests {
+			t.Errorf("%s %d: expected %d requests, got %d", c.method, c.status, c.requests, server.requests)
+		}
+		if resp.StatusCode != c.returned {
+			t.Errorf("%s %d: expected status %d, got %s", c.method, c.status, c.returned, resp.Status)
+		}
+		if c.returned == http.StatusOK && string(body) != "body" {
+			t.Errorf("%s %d: expected the body to be echoed, got %q", c.method, c.status, body)
+		}
+	}
+}
+
+func TestRetryTransportVcdError(t *testing.T) {
+	server := &testRetryServer{status: http.StatusTooManyRequests, failures: retryMaxAttempts}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := &govcd.Client{Http: http.Client{Transport: &retryTransport{base: http.DefaultTransport}}}
+	task := govcd.NewTask(client)
+	task.Task.HREF = ts.URL + "/api/task/1"
+
+	err := task.Refresh()
+	if vcdError := findVcdError(err); vcdError == nil || vcdError.MajorErrorCode != http.StatusTooManyRequests {
+		t.Errorf("expected a vCD error with major code 429, got %v", err)
+	}
+	if !isThrottled(err) || !isTransientError(err) {
+		t.Errorf("expected a throttled error, got %v", err)
+	}
+	if server.requests != retryMaxAttempts {
+		t.Errorf("expected %d requests, got %d", retryMaxAttempts, server.requests)
+	}
+}
+
+func TestRetryTransportThrottledPost(t *testing.T) {
+	server := &testRetryServer{status: http.StatusTooManyRequests, failures: 1}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := &govcd.Client{Http: http.Client{Transport: &retryTransport{base: http.DefaultTransport}}}
+	err := doXMLRequest(client, http.MethodPost, ts.URL+"/api/vApp/vm-1/action/createSnapshot", "", nil, nil)
+
+	// The POST is sent once and left to the retries of the caller
+	if !isThrottled(err) || retryError(err) == nil || !retryError(err).Retryable {
+		t.Errorf("expected a retryable throttled error, got %v", err)
+	}
+	if server.requests != 1 {
+		t.Errorf("expected 1 request, got %d", server.requests)
+	}
+}
@@ -2,9 +2,9 @@ package vcd
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"fmt"
-	"log"
 	"net"
 	"reflect"
 	"strconv"
@@ -38,54 +38,6 @@ func expandIPRange(configured []interface{}) types.IPRanges {
 	return ipRanges
 }
 
-func expandFirewallRules(d *schema.ResourceData, gateway *types.EdgeGateway) ([]*types.FirewallRule, error) {
-	//firewallRules := make([]*types.FirewallRule, 0, len(configured))
-	firewallRules := gateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.FirewallRule
-
-	rulesCount := d.Get("rule.#").(int)
-	for i := 0; i < rulesCount; i++ {
-		prefix := fmt.Sprintf("rule.%d", i)
-
-		var protocol *types.FirewallRuleProtocols
-		switch d.Get(prefix + ".protocol").(string) {
-		case "tcp":
-			protocol = &types.FirewallRuleProtocols{
-				TCP: true,
-			}
-		case "udp":
-			protocol = &types.FirewallRuleProtocols{
-				UDP: true,
-			}
-		case "icmp":
-			protocol = &types.FirewallRuleProtocols{
-				ICMP: true,
-			}
-		default:
-			protocol = &types.FirewallRuleProtocols{
-				Any: true,
-			}
-		}
-		rule := &types.FirewallRule{
-			//ID: strconv.Itoa(len(configured) - i),
-			IsEnabled:            true,
-			MatchOnTranslate:     false,
-			Description:          d.Get(prefix + ".description").(string),
-			Policy:               d.Get(prefix + ".policy").(string),
-			Protocols:            protocol,
-			Port:                 getNumericPort(d.Get(prefix + ".destination_port")),
-			DestinationPortRange: d.Get(prefix + ".destination_port").(string),
-			DestinationIP:        d.Get(prefix + ".destination_ip").(string),
-			SourcePort:           getNumericPort(d.Get(prefix + ".source_port")),
-			SourcePortRange:      d.Get(prefix + ".source_port").(string),
-			SourceIP:             d.Get(prefix + ".source_ip").(string),
-			EnableLogging:        false,
-		}
-		firewallRules = append(firewallRules, rule)
-	}
-
-	return firewallRules, nil
-}
-
 func getProtocol(protocol types.FirewallRuleProtocols) string {
 	if protocol.TCP {
 		return "tcp"
@@ -115,55 +67,70 @@ func getPortString(port int) string {
 	return portstring
 }
 
-func retryCall(seconds int, f resource.RetryFunc) error {
-	return resource.Retry(time.Duration(seconds)*time.Second, f)
+// retryCall retries f until the context ends, or until the max_retry_timeout
+// of the provider when it is set
+func retryCall(ctx context.Context, f resource.RetryFunc) error {
+	timeout := resourceTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if maxRetryTimeout, ok := ctx.Value(maxRetryTimeoutKey{}).(time.Duration); ok {
+		f = boundRetries(f, maxRetryTimeout, time.Now)
+	}
+
+	return resource.Retry(timeout, func() *resource.RetryError {
+		if err := ctx.Err(); err != nil {
+			return resource.NonRetryableError(err)
+		}
+		return f()
+	})
 }
 
-func retryCallWithVAppErrorHandling(seconds int, f func() (govcloudair.Task, error)) error {
-	return retryCall(seconds, func() *resource.RetryError {
+// boundRetries makes the errors of f final once maxRetryTimeout has passed
+// since its first call
+func boundRetries(f resource.RetryFunc, maxRetryTimeout time.Duration, now func() time.Time) resource.RetryFunc {
+	var deadline time.Time
+	return func() *resource.RetryError {
+		if deadline.IsZero() {
+			deadline = now().Add(maxRetryTimeout)
+		}
+
+		result := f()
+		if result != nil && result.Retryable && now().After(deadline) {
+			return resource.NonRetryableError(result.Err)
+		}
+		return result
+	}
+}
+
+// retryCallWithVAppErrorHandling starts a task of a vApp with f and waits for
+// it. Concurrent changes of a vApp may fail with an internal error, which is
+// retried as well as the transient errors.
+func retryCallWithVAppErrorHandling(ctx context.Context, f func() (govcloudair.Task, error)) error {
+	return retryCall(ctx, func() *resource.RetryError {
 		task, err := f()
+		if isInternalServerError(err) {
+			return resource.RetryableError(err)
+		}
 		if err != nil {
-			switch err.(type) {
-			default:
-				log.Printf("[TRACE] ERROR: %#v", err)
-				return resource.NonRetryableError(fmt.Errorf("Error adding in retry call: %#v", err))
-			case *types.Error:
-				vmError := err.(*types.Error)
-				if vmError.MajorErrorCode == 500 &&
-					vmError.MinorErrorCode == "INTERNAL_SERVER_ERROR" {
-					return resource.RetryableError(err)
-				}
-				if vmError.MajorErrorCode == 400 &&
-					vmError.MinorErrorCode == "BUSY_ENTITY" {
-					return resource.RetryableError(err)
-				}
-				return resource.NonRetryableError(fmt.Errorf("Error adding in retry call: %#v", err))
-			}
+			return retryError(err)
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return waitTask(ctx, task)
 	})
 }
 
-func retryCallWithBusyEntityErrorHandling(seconds int, f func() (govcloudair.Task, error)) error {
-	return retryCall(seconds, func() *resource.RetryError {
+// retryCallWithBusyEntityErrorHandling starts a task with f and waits for it,
+// the task is started again while it fails for a transient reason
+func retryCallWithBusyEntityErrorHandling(ctx context.Context, f func() (govcloudair.Task, error)) error {
+	return retryCall(ctx, func() *resource.RetryError {
 		task, err := f()
 		if err != nil {
-			switch err.(type) {
-			default:
-				log.Printf("[TRACE] ERROR: %#v", err)
-				return resource.NonRetryableError(fmt.Errorf("Error adding in retry call: %#v", err))
-			case *types.Error:
-				vmError := err.(*types.Error)
-				if vmError.MajorErrorCode == 400 &&
-					vmError.MinorErrorCode == "BUSY_ENTITY" {
-					return resource.RetryableError(err)
-				}
-				return resource.NonRetryableError(fmt.Errorf("Error adding in retry call: %#v", err))
-			}
+			return retryError(err)
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return waitTask(ctx, task)
 	})
 }
 
@@ -0,0 +1,161 @@
+package vcd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// Default timeouts of the resources. Edge gateway changes are expected to
+// complete quickly, while deploying a vApp copies the disks of its template.
+const (
+	edgeGatewayTimeout = 5 * time.Minute
+	resourceTimeout    = 20 * time.Minute
+	deployTimeout      = 40 * time.Minute
+)
+
+// Tasks are polled often at first, then less and less often as most of them
+// complete within seconds while a deploy can take many minutes
+const (
+	taskPollInterval    = 1 * time.Second
+	taskPollMaxInterval = 15 * time.Second
+)
+
+// taskError is a task which completed without success, it holds the error
+// vCD reported for it
+type taskError struct {
+	task *types.Task
+}
+
+func (e *taskError) Error() string {
+	task := e.task
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "task %s", task.Name)
+	if task.Operation != "" {
+		fmt.Fprintf(&b, " (%s)", task.Operation)
+	}
+	if task.Owner != nil {
+		fmt.Fprintf(&b, " of %s %s", task.Owner.Name, task.Owner.HREF)
+	}
+	fmt.Fprintf(&b, " %s", task.Status)
+
+	switch {
+	case task.Error != nil:
+		fmt.Fprintf(&b, ": %s (%d %s)", task.Error.Message, task.Error.MajorErrorCode, task.Error.MinorErrorCode)
+	case task.Details != "":
+		fmt.Fprintf(&b, ": %s", task.Details)
+	case task.Description != "":
+		fmt.Fprintf(&b, ": %s", task.Description)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the error vCD reported for the task, it classifies the
+// failure of the task
+func (e *taskError) Unwrap() error {
+	if e.task.Error == nil {
+		return nil
+	}
+	return e.task.Error
+}
+
+// taskCancelledError is returned for a task which was still running when the
+// operation timed out or Terraform was interrupted, the task is cancelled
+type taskCancelledError struct {
+	name   string
+	reason error
+}
+
+func (e *taskCancelledError) Error() string {
+	if e.reason == context.DeadlineExceeded {
+		return fmt.Sprintf("task %s did not complete before the timeout, it has been cancelled", e.name)
+	}
+	return fmt.Sprintf("task %s has been cancelled: %s", e.name, e.reason)
+}
+
+// maxRetryTimeoutKey is the context key of the deprecated max_retry_timeout
+// of the provider
+type maxRetryTimeoutKey struct{}
+
+// operationContext returns the context of the given operation on the
+// resource. It ends at the timeout set by the timeouts block of the resource,
+// or when Terraform is interrupted.
+func (c *VCDClient) operationContext(d *schema.ResourceData, key string) (context.Context, context.CancelFunc) {
+	ctx := c.stopContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.maxRetryTimeout > 0 {
+		ctx = context.WithValue(ctx, maxRetryTimeoutKey{}, c.maxRetryTimeout)
+	}
+	return context.WithTimeout(ctx, d.Timeout(key))
+}
+
+// changeContext returns the context of the creation or the update of the
+// resource, or of its deletion when remove is set. It is used by the helpers
+// shared by these operations.
+func (c *VCDClient) changeContext(d *schema.ResourceData, remove bool) (context.Context, context.CancelFunc) {
+	switch {
+	case remove:
+		return c.operationContext(d, schema.TimeoutDelete)
+	case d.IsNewResource():
+		return c.operationContext(d, schema.TimeoutCreate)
+	default:
+		return c.operationContext(d, schema.TimeoutUpdate)
+	}
+}
+
+// waitTaskCompletion polls the task until it completes, with an interval
+// growing up to taskPollMaxInterval. A task still running when the context
+// ends is cancelled.
+func waitTaskCompletion(ctx context.Context, task govcd.Task) error {
+	interval := taskPollInterval
+
+	for {
+		err := task.Refresh()
+		if err != nil {
+			return err
+		}
+
+		switch task.Task.Status {
+		case "queued", "preRunning", "running":
+		case "success":
+			return nil
+		default:
+			return &taskError{task: task.Task}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[INFO] Cancelling task %s: %s", task.Task.Name, task.Task.HREF)
+			if task.Task.Link != nil {
+				err = task.Cancel()
+				if err != nil {
+					log.Printf("[WARN] Cannot cancel task %s: %s", task.Task.HREF, err)
+				}
+			}
+			return &taskCancelledError{name: task.Task.Name, reason: ctx.Err()}
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > taskPollMaxInterval {
+			interval = taskPollMaxInterval
+		}
+	}
+}
+
+// waitTask waits for a task started in a retryCall loop, the task is started
+// again only when it failed for a transient reason
+func waitTask(ctx context.Context, task govcd.Task) *resource.RetryError {
+	return retryError(waitTaskCompletion(ctx, task))
+}
@@ -0,0 +1,130 @@
+package vcd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+)
+
+// testTaskServer serves a task which stays in the given status with the given
+// error, and records whether it has been cancelled
+type testTaskServer struct {
+	status    string
+	error     string
+	cancelled bool
+}
+
+func (s *testTaskServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/task/1":
+		fmt.Fprintf(w, `<Task xmlns="http://www.vmware.com/vcloud/v1.5" name="task" status="%s" operation="Deploying web" href="http://%s/api/task/1">`+
+			`<Link rel="task:cancel" href="http://%s/api/task/1/action/cancel"/>`+
+			`<Owner name="web" href="http://%s/api/vApp/vapp-1"/>%s</Task>`, s.status, r.Host, r.Host, r.Host, s.error)
+	case "/api/task/1/action/cancel":
+		s.cancelled = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestWaitTaskCompletion(t *testing.T) {
+	cases := []struct {
+		status    string
+		error     string
+		message   string
+		retryable bool
+		cancelled bool
+	}{
+		{status: "success"},
+		{
+			status:  "error",
+			error:   `<Error message="No space left" majorErrorCode="500" minorErrorCode="INTERNAL_SERVER_ERROR"/>`,
+			message: "task task (Deploying web) of web http://",
+		},
+		{
+			status:    "error",
+			error:     `<Error message="The entity is busy" majorErrorCode="400" minorErrorCode="BUSY_ENTITY"/>`,
+			message:   "error: The entity is busy (400 BUSY_ENTITY)",
+			retryable: true,
+		},
+		{status: "running", message: "has been cancelled", cancelled: true},
+	}
+
+	for _, c := range cases {
+		server := &testTaskServer{status: c.status, error: c.error}
+		ts := httptest.NewServer(server)
+
+		task := govcd.NewTask(&govcd.Client{})
+		task.Task.HREF = ts.URL + "/api/task/1"
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := waitTaskCompletion(ctx, *task)
+		retryErr := waitTask(ctx, *task)
+		ts.Close()
+
+		if c.message == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.status, err)
+		}
+		if c.message != "" && (err == nil || !strings.Contains(err.Error(), c.message)) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.status, c.message, err)
+		}
+		if retryErr != nil && retryErr.Retryable != c.retryable {
+			t.Errorf("%s: expected retryable=%t", c.status, c.retryable)
+		}
+		if server.cancelled != c.cancelled {
+			t.Errorf("%s: expected cancelled=%t", c.status, c.cancelled)
+		}
+	}
+}
+
+func TestOperationContextMaxRetryTimeout(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{}, map[string]interface{}{})
+
+	for _, maxRetryTimeout := range []time.Duration{0, time.Minute} {
+		vcdClient := &VCDClient{maxRetryTimeout: maxRetryTimeout}
+		ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+		value, ok := ctx.Value(maxRetryTimeoutKey{}).(time.Duration)
+		cancel()
+
+		if ok != (maxRetryTimeout > 0) || value != maxRetryTimeout {
+			t.Errorf("%s: expected the max retry timeout to be set when it is not 0, got %s", maxRetryTimeout, value)
+		}
+	}
+}
+
+func TestBoundRetries(t *testing.T) {
+	clock := time.Unix(0, 0)
+	now := func() time.Time { return clock }
+
+	f := boundRetries(func() *resource.RetryError {
+		return resource.RetryableError(fmt.Errorf("busy"))
+	}, time.Minute, now)
+
+	for _, elapsed := range []time.Duration{0, 30 * time.Second, time.Minute} {
+		clock = time.Unix(0, 0).Add(elapsed)
+		if result := f(); result == nil || !result.Retryable {
+			t.Errorf("%s: expected a retryable error, got %v", elapsed, result)
+		}
+	}
+
+	clock = time.Unix(0, 0).Add(time.Minute + time.Millisecond)
+	if result := f(); result == nil || result.Retryable || result.Err.Error() != "busy" {
+		t.Errorf("expected the error to be final after the max retry timeout, got %v", result)
+	}
+
+	f = boundRetries(func() *resource.RetryError { return nil }, time.Minute, now)
+	if result := f(); result != nil {
+		t.Errorf("expected a success to be left alone, got %v", result)
+	}
+}
@@ -0,0 +1,74 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// testReadFixture decodes the XML document testdata/<name>.xml into v
+func testReadFixture(t *testing.T, name string, v interface{}) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name+".xml"))
+	if err != nil {
+		t.Fatalf("cannot read fixture %s: %v", name, err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		t.Fatalf("cannot decode fixture %s: %v", name, err)
+	}
+}
+
+func testReadEdgeGatewayServices(t *testing.T) *edgeGatewayServices {
+	edgeGateway := &edgeGatewayConfiguration{}
+	testReadFixture(t, "edge_gateway_static_routes", edgeGateway)
+	return &edgeGateway.Configuration.EdgeGatewayServiceConfiguration
+}
+
+func testReadEdgeGatewayDhcp(t *testing.T) *types.EdgeGateway {
+	edgeGateway := &types.EdgeGateway{}
+	testReadFixture(t, "edge_gateway_dhcp", edgeGateway)
+	return edgeGateway
+}
+
+func testReadLoadBalancerService(t *testing.T) *loadBalancerService {
+	edgeGateway := &edgeGatewayConfiguration{}
+	testReadFixture(t, "edge_gateway_load_balancer", edgeGateway)
+	return edgeGateway.Configuration.EdgeGatewayServiceConfiguration.LoadBalancerService
+}
+
+func testReadMetadata(t *testing.T) map[string]metadataEntry {
+	metadata := &vcdMetadata{}
+	testReadFixture(t, "metadata", metadata)
+
+	entries := make(map[string]metadataEntry)
+	for _, entry := range metadata.MetadataEntry {
+		entries[entry.Key] = convertMetadataEntry(entry)
+	}
+	return entries
+}
+
+func testReadNatRules(t *testing.T) []*types.NatRule {
+	natService := &types.NatService{}
+	testReadFixture(t, "nat_service", natService)
+	return natService.NatRule
+}
+
+func testReadProductSections(t *testing.T) *productSectionList {
+	sections := &productSectionList{}
+	testReadFixture(t, "product_sections", sections)
+	return sections
+}
+
+func testReadVMDiskItems(t *testing.T) *vmDiskItems {
+	items := &vmDiskItems{}
+	testReadFixture(t, "vm_disk_items", items)
+	return items
+}
+
+func testReadVpnTunnels(t *testing.T) []*ipsecVpnTunnel {
+	ipsecVpn := &ipsecVpnService{}
+	testReadFixture(t, "ipsec_vpn_service", ipsecVpn)
+	return ipsecVpn.Tunnel
+}
@@ -1,18 +1,26 @@
 package vcd
 
 import (
+	"encoding/xml"
 	"fmt"
 	"log"
 
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
 	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
 )
 
 func readVApp(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	// Should be fetched by ID/HREF
-	vapp, err := vcdClient.OrgVdc.GetVAppByHREF(d.Id())
+	vapp, err := vdc.GetVAppByHREF(d.Id())
 
 	if err != nil {
 		return fmt.Errorf("Error finding VApp: %#v", err)
@@ -23,7 +31,27 @@ func readVApp(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("error refreshing vApp before running customization: %#v", err)
 	}
 
+	d.Set("name", vapp.VApp.Name)
+	d.Set("description", vapp.VApp.Description)
+	d.Set("href", vapp.VApp.HREF)
+	d.Set("power_on", vapp.VApp.Status == 4)
+	d.Set("vm", flattenVAppVMs(vapp.VApp))
+
+	err = readOvfProperties(d, vapp.VApp.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	err = readMetadataAttributes(d, vapp.VApp.HREF, meta)
+	if err != nil {
+		return err
+	}
+
 	// Reading networks defined on the vApp
+	var networkConfigs []*types.VAppNetworkConfiguration
+	if vapp.VApp.NetworkConfigSection != nil {
+		networkConfigs = vapp.VApp.NetworkConfigSection.NetworkConfig
+	}
 
 	organizationNetworksFromState := interfaceListToStringList(
 		d.Get("organization_network").([]interface{}))
@@ -34,64 +62,46 @@ func readVApp(d *schema.ResourceData, meta interface{}) error {
 	readOrgNetworks := make([]string, 0)
 	readVAppNetworks := make([]map[string]interface{}, 0)
 
-	// // Order is not guarenteed, so we will have to check what we have first
-	// // First look for networks that we already have
+	// Order is not guarenteed, so first look for networks that we already
+	// have to keep the order of the configuration
 	for _, network := range organizationNetworksFromState {
-		vAppNetwork, _ := vapp.GetNetworkByName(network)
-		if err != nil {
-			return err
-		}
-
-		if vAppNetwork != nil {
+		vAppNetwork := findVAppNetworkConfiguration(networkConfigs, network)
+		if vAppNetwork != nil && isOrgNetworkConfiguration(vAppNetwork) {
 			readOrgNetworks = append(readOrgNetworks, network)
 		}
 	}
 
 	for index := range vAppNetworksFromState {
-		vAppNetworkResource := NewVAppNetworkSubresource(vAppNetworksFromState[index], nil)
-		vAppNetwork, _ := vapp.GetNetworkByName(vAppNetworkResource.Get("name").(string))
-		if err != nil {
-			return err
-		}
-
-		if vAppNetwork != nil && vAppNetwork.Configuration != nil {
-			vAppNetworkResource.Set("name", vAppNetwork.NetworkName)
-			vAppNetworkResource.Set("gateway",
-				vAppNetwork.Configuration.IPScopes.IPScope[0].Gateway)
-			vAppNetworkResource.Set("netmask",
-				vAppNetwork.Configuration.IPScopes.IPScope[0].Netmask)
-			vAppNetworkResource.Set("dns1",
-				vAppNetwork.Configuration.IPScopes.IPScope[0].DNS1)
-			vAppNetworkResource.Set("dns2",
-				vAppNetwork.Configuration.IPScopes.IPScope[0].DNS2)
-			vAppNetworkResource.Set("start",
-				vAppNetwork.Configuration.IPScopes.IPScope[0].IPRanges.IPRange[0].StartAddress)
-			vAppNetworkResource.Set("end",
-				vAppNetwork.Configuration.IPScopes.IPScope[0].IPRanges.IPRange[0].EndAddress)
-
-			if vAppNetwork.Configuration.ParentNetwork != nil {
-				vAppNetworkResource.Set("parent",
-					vAppNetwork.Configuration.ParentNetwork.Name)
-			}
+		name, _ := vAppNetworksFromState[index]["name"].(string)
+		vAppNetwork := findVAppNetworkConfiguration(networkConfigs, name)
+		if vAppNetwork != nil && !isOrgNetworkConfiguration(vAppNetwork) && vAppNetwork.Configuration != nil {
+			readVAppNetworks = append(readVAppNetworks,
+				readVAppNetworkConfiguration(vAppNetwork, vAppNetworksFromState[index]))
+		}
+	}
 
-			if vAppNetwork.Configuration.Features != nil {
-				if vAppNetwork.Configuration.Features.NatService != nil {
-					vAppNetworkResource.Set("nat",
-						vAppNetwork.Configuration.Features.NatService.IsEnabled)
-				}
+	// Then append the networks which are not known yet, e.g. on import or
+	// when they were added outside of terraform
+	for _, vAppNetwork := range networkConfigs {
+		if vAppNetwork.NetworkName == "none" {
+			continue
+		}
 
-				if vAppNetwork.Configuration.Features.DhcpService != nil {
-					vAppNetworkResource.Set("dhcp",
-						vAppNetwork.Configuration.Features.DhcpService.IsEnabled)
-				}
+		if isOrgNetworkConfiguration(vAppNetwork) {
+			if !isStringMember(readOrgNetworks, vAppNetwork.NetworkName) {
+				readOrgNetworks = append(readOrgNetworks, vAppNetwork.NetworkName)
 			}
+			continue
+		}
 
-			readVAppNetworks = append(readVAppNetworks, vAppNetworkResource.Data())
+		if vAppNetwork.Configuration != nil && !isNetworkNameMember(readVAppNetworks, vAppNetwork.NetworkName) {
+			readVAppNetworks = append(readVAppNetworks,
+				readVAppNetworkConfiguration(vAppNetwork, map[string]interface{}{}))
 		}
 	}
 
 	log.Printf("[TRACE] Org Networks defined for vApp (%s) is: %#v", vapp.VApp.Name, readOrgNetworks)
-	log.Printf("[TRACE] vApp Networks defined for vApp (%s) is: %#v", vapp.VApp.Name, readOrgNetworks)
+	log.Printf("[TRACE] vApp Networks defined for vApp (%s) is: %#v", vapp.VApp.Name, readVAppNetworks)
 
 	d.Set("organization_network", readOrgNetworks)
 	d.Set("vapp_network", readVAppNetworks)
@@ -100,16 +110,91 @@ func readVApp(d *schema.ResourceData, meta interface{}) error {
 
 }
 
+func readVAppNetworkConfiguration(vAppNetwork *types.VAppNetworkConfiguration, data map[string]interface{}) map[string]interface{} {
+	vAppNetworkResource := NewVAppNetworkSubresource(data, nil)
+	configuration := vAppNetwork.Configuration
+
+	vAppNetworkResource.Set("name", vAppNetwork.NetworkName)
+	vAppNetworkResource.Set("description", vAppNetwork.Description)
+
+	if configuration.IPScopes != nil && len(configuration.IPScopes.IPScope) > 0 {
+		ipScope := configuration.IPScopes.IPScope[0]
+		vAppNetworkResource.Set("gateway", ipScope.Gateway)
+		vAppNetworkResource.Set("netmask", ipScope.Netmask)
+		vAppNetworkResource.Set("dns1", ipScope.DNS1)
+		vAppNetworkResource.Set("dns2", ipScope.DNS2)
+
+		if ipScope.IPRanges != nil && len(ipScope.IPRanges.IPRange) > 0 {
+			vAppNetworkResource.Set("start", ipScope.IPRanges.IPRange[0].StartAddress)
+			vAppNetworkResource.Set("end", ipScope.IPRanges.IPRange[0].EndAddress)
+		}
+	}
+
+	if configuration.ParentNetwork != nil {
+		vAppNetworkResource.Set("parent", configuration.ParentNetwork.Name)
+	}
+
+	vAppNetworkResource.Set("nat", false)
+	vAppNetworkResource.Set("dhcp", false)
+	if configuration.Features != nil {
+		if configuration.Features.NatService != nil {
+			vAppNetworkResource.Set("nat", configuration.Features.NatService.IsEnabled)
+		}
+
+		if dhcp := configuration.Features.DhcpService; dhcp != nil {
+			vAppNetworkResource.Set("dhcp", dhcp.IsEnabled)
+			if dhcp.IsEnabled && dhcp.IPRange != nil {
+				vAppNetworkResource.Set("dhcp_start", dhcp.IPRange.StartAddress)
+				vAppNetworkResource.Set("dhcp_end", dhcp.IPRange.EndAddress)
+			}
+		}
+	}
+
+	return vAppNetworkResource.Data()
+}
+
+// An organization network is attached to a vApp as a bridged network of the
+// same name, see orgVDCNetworkToNetworkConfiguration
+func isOrgNetworkConfiguration(vAppNetwork *types.VAppNetworkConfiguration) bool {
+	return vAppNetwork.Configuration != nil &&
+		vAppNetwork.Configuration.FenceMode == types.FenceModeBridged &&
+		vAppNetwork.Configuration.ParentNetwork != nil &&
+		vAppNetwork.Configuration.ParentNetwork.Name == vAppNetwork.NetworkName
+}
+
+func findVAppNetworkConfiguration(networks []*types.VAppNetworkConfiguration, name string) *types.VAppNetworkConfiguration {
+	for _, network := range networks {
+		if network.NetworkName == name {
+			return network
+		}
+	}
+	return nil
+}
+
+func isNetworkNameMember(list []map[string]interface{}, name string) bool {
+	for _, item := range list {
+		if item["name"] == name {
+			return true
+		}
+	}
+	return false
+}
+
 func createNetworkConfiguration(d *schema.ResourceData, meta interface{}) ([]*types.VAppNetworkConfiguration, error) {
 	vcdClient := meta.(*VCDClient)
 
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
 	// Organization Network
 	organizationNetworks := d.Get("organization_network").([]interface{})
 	log.Printf("[TRACE] Networks from state: %#v", organizationNetworks)
 
 	orgnetworks := make([]*types.VAppNetworkConfiguration, len(organizationNetworks))
 	for index, network := range organizationNetworks {
-		orgnetwork, err := vcdClient.OrgVdc.FindVDCNetwork(network.(string))
+		orgnetwork, err := vdc.FindVDCNetwork(network.(string))
 		if err != nil {
 			return nil, fmt.Errorf("Error finding vdc org network: %s, %#v", network, err)
 		}
@@ -169,7 +254,7 @@ func createNetworkConfiguration(d *schema.ResourceData, meta interface{}) ([]*ty
 				// We need to set parent
 			}
 
-			orgnetwork, err := vcdClient.OrgVdc.FindVDCNetwork(vAppNetwork.Get("parent").(string))
+			orgnetwork, err := vdc.FindVDCNetwork(vAppNetwork.Get("parent").(string))
 
 			if err != nil {
 				return nil, fmt.Errorf("Error finding vdc org network: %s, %#v", vAppNetwork.Get("parent").(string), err)
@@ -215,3 +300,239 @@ func networkInList(networks []string, vAppNetwork *types.VAppNetworkConfiguratio
 	}
 	return false
 }
+
+// vAppVMSchema describes the computed attributes of a VM of a vApp
+func vAppVMSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"href": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"computer_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			// The IP of the primary network connection
+			"ip": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ip_addresses": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func flattenVAppVMs(vapp *types.VApp) []map[string]interface{} {
+	vms := make([]map[string]interface{}, 0)
+	if vapp.Children == nil {
+		return vms
+	}
+
+	for _, vm := range vapp.Children.VM {
+		primaryIP := ""
+		ipAddresses := make([]string, 0)
+		if section := vm.NetworkConnectionSection; section != nil {
+			for _, networkConnection := range section.NetworkConnection {
+				if networkConnection.IPAddress == "" {
+					continue
+				}
+				ipAddresses = append(ipAddresses, networkConnection.IPAddress)
+				if networkConnection.NetworkConnectionIndex == section.PrimaryNetworkConnectionIndex {
+					primaryIP = networkConnection.IPAddress
+				}
+			}
+		}
+
+		computerName := ""
+		if vm.GuestCustomizationSection != nil {
+			computerName = vm.GuestCustomizationSection.ComputerName
+		}
+
+		vms = append(vms, map[string]interface{}{
+			"name":          vm.Name,
+			"href":          vm.HREF,
+			"status":        types.VAppStatuses[vm.Status],
+			"computer_name": computerName,
+			"ip":            primaryIP,
+			"ip_addresses":  ipAddresses,
+		})
+	}
+
+	return vms
+}
+
+// instantiateVAppTemplateParams mirrors types.InstantiateVAppTemplateParams,
+// the vendored instantiation params put the product section into the vCloud
+// namespace which vCD rejects
+type instantiateVAppTemplateParams struct {
+	XMLName xml.Name `xml:"InstantiateVAppTemplateParams"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Ovf     string   `xml:"xmlns:ovf,attr"`
+
+	Name    string `xml:"name,attr"`
+	Deploy  bool   `xml:"deploy,attr"`
+	PowerOn bool   `xml:"powerOn,attr"`
+
+	Description         string                   `xml:"Description,omitempty"`
+	InstantiationParams *vAppInstantiationParams `xml:"InstantiationParams,omitempty"`
+	Source              *types.Reference         `xml:"Source"`
+	AllEULAsAccepted    bool                     `xml:"AllEULAsAccepted,omitempty"`
+}
+
+type vAppInstantiationParams struct {
+	NetworkConfigSection *types.NetworkConfigSection `xml:"NetworkConfigSection,omitempty"`
+	ProductSection       *ovfProductSection          `xml:"ovf:ProductSection,omitempty"`
+}
+
+// validateVAppTemplateArguments checks that catalog_name and template_name are
+// set together, and that a vApp powered on at creation is instantiated
+func validateVAppTemplateArguments(powerOn bool, isSet func(string) bool) error {
+	if isSet("catalog_name") && !isSet("template_name") {
+		return errors.New("template_name is required with catalog_name")
+	}
+	if isSet("template_name") && !isSet("catalog_name") {
+		return errors.New("catalog_name is required with template_name")
+	}
+	if powerOn && !isSet("template_name") {
+		return errors.New("power_on requires a vApp created from a template")
+	}
+
+	return nil
+}
+
+// instantiateVAppTemplate creates the vApp from the catalog_name and the
+// template_name of the configuration with all the VMs of the template. The id
+// of the resource is set as soon as vCD has accepted the instantiation.
+func instantiateVAppTemplate(d *schema.ResourceData, meta interface{}, networks []*types.VAppNetworkConfiguration) (*types.VApp, error) {
+	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := org.FindCatalog(d.Get("catalog_name").(string))
+	if err != nil {
+		return nil, fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	catalogitem, err := catalog.FindCatalogItem(d.Get("template_name").(string))
+	if err != nil {
+		return nil, fmt.Errorf("Error finding catalog item: %#v", err)
+	}
+
+	vapptemplate, err := catalogitem.GetVAppTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("Error finding VAppTemplate: %#v", err)
+	}
+
+	powerOn := d.Get("power_on").(bool)
+	params := &instantiateVAppTemplateParams{
+		Xmlns:            types.XMLNamespaceXMLNS,
+		Ovf:              types.XMLNamespaceOVF,
+		Name:             d.Get("name").(string),
+		Deploy:           powerOn,
+		PowerOn:          powerOn,
+		Description:      d.Get("description").(string),
+		Source:           &types.Reference{HREF: vapptemplate.VAppTemplate.HREF},
+		AllEULAsAccepted: d.Get("accept_all_eulas").(bool),
+	}
+
+	instantiationParams := &vAppInstantiationParams{}
+	// The networks of the template are kept when none are configured
+	if len(networks) > 0 {
+		instantiationParams.NetworkConfigSection = &types.NetworkConfigSection{
+			Info:          "Configuration parameters for logical networks",
+			NetworkConfig: networks,
+		}
+	}
+	// The properties have to be known on the first power on of the guest
+	properties := d.Get("ovf_properties").(map[string]interface{})
+	if len(properties) > 0 {
+		instantiationParams.ProductSection = expandOvfProperties(properties)
+	}
+	if instantiationParams.NetworkConfigSection != nil || instantiationParams.ProductSection != nil {
+		params.InstantiationParams = instantiationParams
+	}
+
+	link := vdc.Vdc.Link.ForType(types.MimeInstantiateVAppTemplate, types.RelAdd)
+	if link == nil {
+		return nil, errors.Errorf("cannot find endpoint: type=%s, rel=%s", types.MimeInstantiateVAppTemplate, types.RelAdd)
+	}
+
+	log.Printf("[INFO] Instantiating vApp template '%s' as vApp '%s'", vapptemplate.VAppTemplate.Name, params.Name)
+
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	// The instantiation is not retried, a failed request may have created
+	// the vApp already
+	vapp := &types.VApp{}
+	err = doXMLRequest(&vcdClient.Client, "POST", link.HREF, types.MimeInstantiateVAppTemplate, params, vapp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot instantiate vApp template: name=%s", params.Name)
+	}
+
+	// The vApp exists from now on, a failure of its deployment taints the
+	// resource rather than leaving a vApp which is not in the state
+	d.SetId(vapp.HREF)
+
+	if vapp.Tasks == nil || len(vapp.Tasks.Task) == 0 {
+		return nil, errors.Errorf("no task returned for the instantiation of vApp '%s'", params.Name)
+	}
+
+	task := govcd.NewTask(&vcdClient.Client)
+	task.Task = vapp.Tasks.Task[0]
+	err = waitTaskCompletion(ctx, *task)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot instantiate vApp template: name=%s", params.Name)
+	}
+
+	return vapp, nil
+}
+
+// powerVApp brings the vApp into the power state of the power_on attribute
+func powerVApp(d *schema.ResourceData, vapp *govcd.VApp, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.changeContext(d, false)
+	defer cancel()
+
+	status, err := vapp.GetStatus()
+	if err != nil {
+		return fmt.Errorf("Error getting VApp status: %#v, %s", err, status)
+	}
+
+	if d.Get("power_on").(bool) && status != types.VAppStatuses[4] {
+		log.Printf("[DEBUG] (%s) Powering on vApp", vapp.VApp.Name)
+		return retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return govcd.ExecuteRequest("", vapp.VApp.HREF+"/power/action/powerOn", "POST", "", &vcdClient.Client)
+		})
+	}
+
+	if !d.Get("power_on").(bool) && status == types.VAppStatuses[4] {
+		log.Printf("[DEBUG] (%s) Powering off vApp", vapp.VApp.Name)
+		return retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return vapp.Undeploy()
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func TestInstantiateVAppTemplateParamsXML(t *testing.T) {
+	params := &instantiateVAppTemplateParams{
+		Xmlns:  types.XMLNamespaceXMLNS,
+		Ovf:    types.XMLNamespaceOVF,
+		Name:   "appliance",
+		Source: &types.Reference{HREF: "https://vcd.example.com/api/vAppTemplate/vappTemplate-1"},
+		InstantiationParams: &vAppInstantiationParams{
+			ProductSection: expandOvfProperties(map[string]interface{}{
+				"hostname": "appliance-1",
+				"dns":      "10.0.0.2",
+			}),
+		},
+	}
+
+	output, err := xml.Marshal(params)
+	if err != nil {
+		t.Fatalf("cannot marshal params: %v", err)
+	}
+
+	for _, expected := range []string{
+		`<InstantiationParams><ovf:ProductSection><ovf:Info>`,
+		`<Source href="https://vcd.example.com/api/vAppTemplate/vappTemplate-1"`,
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("expected %s in %s", expected, output)
+		}
+	}
+
+	// The properties are sorted by key
+	if strings.Index(string(output), "10.0.0.2") > strings.Index(string(output), "appliance-1") {
+		t.Errorf("expected the properties to be sorted by key: %s", output)
+	}
+}
+
+func TestVAppTemplateArgumentsDiff(t *testing.T) {
+	cases := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{config: map[string]interface{}{}, valid: true},
+		{config: map[string]interface{}{"catalog_name": "catalog", "template_name": "template", "power_on": true}, valid: true},
+		{config: map[string]interface{}{"catalog_name": "catalog"}},
+		{config: map[string]interface{}{"template_name": "template"}},
+		{config: map[string]interface{}{"power_on": true}},
+		{config: map[string]interface{}{"power_on": false}, valid: true},
+	}
+
+	for _, c := range cases {
+		c.config["name"] = "web"
+		_, err := resourceVcdVApp().Diff(nil, terraform.NewResourceConfigRaw(c.config), nil)
+		if (err == nil) != c.valid {
+			t.Errorf("%v: expected valid=%t, got %v", c.config, c.valid, err)
+		}
+	}
+}
+
+func TestFlattenVAppVMs(t *testing.T) {
+	vapp := &types.VApp{
+		Children: &types.VAppChildren{
+			VM: []*types.VM{
+				{
+					Name:   "db",
+					HREF:   "https://vcd.example.com/api/vApp/vm-1",
+					Status: 4,
+					NetworkConnectionSection: &types.NetworkConnectionSection{
+						PrimaryNetworkConnectionIndex: 1,
+						NetworkConnection: []*types.NetworkConnection{
+							{NetworkConnectionIndex: 0, IPAddress: "192.168.1.10"},
+							{NetworkConnectionIndex: 1, IPAddress: "10.0.0.10"},
+							{NetworkConnectionIndex: 2},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	vms := flattenVAppVMs(vapp)
+	if len(vms) != 1 {
+		t.Fatalf("expected 1 VM, got %#v", vms)
+	}
+
+	vm := vms[0]
+	if vm["name"] != "db" || vm["status"] != "POWERED_ON" || vm["ip"] != "10.0.0.10" {
+		t.Errorf("unexpected VM attributes: %#v", vm)
+	}
+	if ips := vm["ip_addresses"].([]string); len(ips) != 2 {
+		t.Errorf("expected 2 IP addresses, got %#v", ips)
+	}
+
+	if vms := flattenVAppVMs(&types.VApp{}); len(vms) != 0 {
+		t.Errorf("expected no VMs for an empty vApp, got %#v", vms)
+	}
+}
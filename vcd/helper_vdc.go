@@ -2,14 +2,16 @@ package vcd
 
 import (
 	"fmt"
+
+	govcd "github.com/kublr/govcloudair"
 	"github.com/pkg/errors"
 )
 
-func findDefaultStorageProfile(vcdClient *VCDClient) (string, error) {
+func findDefaultStorageProfile(vcdClient *VCDClient, vdc *govcd.Vdc) (string, error) {
 	queryParams := map[string]string{
 		"type":          "orgVdcStorageProfile",
 		"format":        "records",
-		"filter":        fmt.Sprintf("(vdcName==%s;isDefaultStorageProfile==true)", vcdClient.OrgVdc.Vdc.Name),
+		"filter":        fmt.Sprintf("(vdcName==%s;isDefaultStorageProfile==true)", vdc.Vdc.Name),
 		"filterEncoded": "true",
 	}
 
@@ -20,8 +22,28 @@ func findDefaultStorageProfile(vcdClient *VCDClient) (string, error) {
 
 	records := query.Results.OrgVdcStorageProfileRecord
 	if len(records) < 1 {
-		return "", fmt.Errorf("no storage profiles found: vdcName%s", vcdClient.OrgVdc.Vdc.Name)
+		return "", fmt.Errorf("no storage profiles found: vdcName%s", vdc.Vdc.Name)
 	}
 
 	return records[0].Name, nil
 }
+
+// vdcStorageProfile is the part of a storage profile of a vdc which is read,
+// the vendored types have no storage profile
+type vdcStorageProfile struct {
+	Name    string `xml:"name,attr"`
+	Default bool   `xml:"Default"`
+}
+
+// findStorageProfileName returns the name of a storage profile of the vdc by
+// its HREF, the HREF is returned when it is not found
+func findStorageProfileName(vdc *govcd.Vdc, href string) string {
+	for _, storageProfiles := range vdc.Vdc.VdcStorageProfiles {
+		for _, storageProfile := range storageProfiles.VdcStorageProfile {
+			if storageProfile.HREF == href {
+				return storageProfile.Name
+			}
+		}
+	}
+	return href
+}
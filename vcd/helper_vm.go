@@ -5,7 +5,6 @@ import (
 	"github.com/pkg/errors"
 	"log"
 
-	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
 	"github.com/kublr/govcloudair/types/v56"
@@ -29,7 +28,17 @@ import (
 func composeSourceItem(d *schema.ResourceData, meta interface{}) (*types.SourcedCompositionItemParam, error) {
 	vcdClient := meta.(*VCDClient)
 
-	catalog, err := vcdClient.Org.FindCatalog(d.Get("catalog_name").(string))
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := org.FindCatalog(d.Get("catalog_name").(string))
 	if err != nil {
 		return nil, fmt.Errorf("Error finding catalog: %#v", err)
 	}
@@ -66,13 +75,13 @@ func composeSourceItem(d *schema.ResourceData, meta interface{}) (*types.Sourced
 
 	storageProfileName := d.Get("storage_profile").(string)
 	if storageProfileName == "" {
-		storageProfileName, err = findDefaultStorageProfile(vcdClient)
+		storageProfileName, err = findDefaultStorageProfile(vcdClient, vdc)
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot find default storage profile")
 		}
 	}
 
-	storageProfile, err := vcdClient.OrgVdc.FindStorageProfileReference(storageProfileName)
+	storageProfile, err := vdc.FindStorageProfileReference(storageProfileName)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +94,11 @@ func composeSourceItem(d *schema.ResourceData, meta interface{}) (*types.Sourced
 func configureVM(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	// Remove network hardware from virtual hw list.
 	vm.RemoveVirtualHardwareItemByResourceType(types.ResourceTypeEthernet)
 
@@ -134,7 +148,7 @@ func configureVM(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
 	if d.HasChange("storage_profile") {
 		log.Printf("[TRACE] (%s) Changing storage profile", d.Get("name").(string))
 		storageProfileName := d.Get("storage_profile").(string)
-		storageProfile, err := vcdClient.OrgVdc.FindStorageProfileReference(storageProfileName)
+		storageProfile, err := vdc.FindStorageProfileReference(storageProfileName)
 		if err != nil {
 			return errors.Wrapf(err, "cannot find storage profile: name=%s", storageProfileName)
 		}
@@ -165,7 +179,9 @@ func configureVM(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
 		log.Printf("[TRACE] (%s) Changing hostname", d.Get("name").(string))
 
 		vm.SetName(d.Get("name").(string))
-		vm.SetHostName(d.Get("name").(string))
+		if !hasComputerName(d) {
+			vm.SetHostName(d.Get("name").(string))
+		}
 	}
 
 	// Change nested hypervisor setting of VM
@@ -175,27 +191,28 @@ func configureVM(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
 		vm.SetInitscript(d.Get("initscript").(string))
 	}
 
-	// Change nested hypervisor setting of VM
+	// The deprecated admin password settings are only sent when they are
+	// set, they conflict with the customization block
 	if d.HasChange("admin_password_enabled") {
 		log.Printf("[TRACE] (%s) Changing admin_password_enabled", d.Get("name").(string))
 
 		vm.VM.GuestCustomizationSection.AdminPasswordEnabled = d.Get("admin_password_enabled").(bool)
 	}
 
-	// Change nested hypervisor setting of VM
 	if d.HasChange("admin_password_auto") {
 		log.Printf("[TRACE] (%s) Changing admin_password_auto", d.Get("name").(string))
 
 		vm.VM.GuestCustomizationSection.AdminPasswordAuto = d.Get("admin_password_auto").(bool)
 	}
 
-	// Change nested hypervisor setting of VM
 	if d.HasChange("admin_password") {
 		log.Printf("[TRACE] (%s) Changing admin_password", d.Get("name").(string))
 
 		vm.VM.GuestCustomizationSection.AdminPassword = d.Get("admin_password").(string)
 	}
 
+	configureVMCustomization(d, vm)
+
 	vm.SetNeedsCustomization(true)
 	// }
 
@@ -204,6 +221,25 @@ func configureVM(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
 	return nil
 }
 
+// vmReconfigurationKeys are the attributes of a VM which are changed while it
+// is powered off
+var vmReconfigurationKeys = []string{
+	"name", "description", "memory", "cpus", "network", "internal_disk", "initscript",
+	"nested_hypervisor_enabled", "storage_profile", "admin_password_enabled",
+	"admin_password_auto", "admin_password", "customization",
+}
+
+// needsVMReconfiguration reports if the update changes an attribute which
+// needs the VM to be powered off
+func needsVMReconfiguration(d *schema.ResourceData) bool {
+	for _, key := range vmReconfigurationKeys {
+		if d.HasChange(key) {
+			return true
+		}
+	}
+	return false
+}
+
 // Before vCloud 9.0, some elements cannot be configured by reconfigureVM,
 // nestedhypervisor and storage profile, this has to be done in seperate calls
 func configureVMWorkaround(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
@@ -217,16 +253,14 @@ func configureVMWorkaround(d *schema.ResourceData, vm *govcd.VM, meta interface{
 		// vm.SetNestedHypervisor(d.Get("nested_hypervisor_enabled").(bool))
 
 		// vCloud 8.2 and older
-		err := retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-			task, err := vm.SetNestedHypervisorWithRequest(d.Get("nested_hypervisor_enabled").(bool))
-			if err != nil {
-				return resource.NonRetryableError(fmt.Errorf("Error setting nested hyperv VM: %#v", err))
-			}
+		ctx, cancel := vcdClient.changeContext(d, false)
+		defer cancel()
 
-			return resource.RetryableError(task.WaitTaskCompletion())
+		err := retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return vm.SetNestedHypervisorWithRequest(d.Get("nested_hypervisor_enabled").(bool))
 		})
 		if err != nil {
-			return fmt.Errorf("Error completing task: %#v", err)
+			return fmt.Errorf("Error completing task: %s", err)
 		}
 	}
 
@@ -242,15 +276,23 @@ func configureVMWorkaround(d *schema.ResourceData, vm *govcd.VM, meta interface{
 func readVM(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] (%s) readVM got d with href %s", d.Get("name").(string), d.Get("href").(string))
 
 	// Get VM object from VCD
-	vm, err := vcdClient.OrgVdc.GetVMByHREF(d.Get("href").(string))
-	if err != nil {
+	vm, err := vdc.GetVMByHREF(d.Get("href").(string))
+	if isGoneByHREF(err) {
 		log.Printf("VM '%s' does not exists. removing from tfstate", d.Id())
 		d.SetId("")
 		return nil
 	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read VM: %s", d.Id())
+	}
 
 	err = vm.Refresh()
 	if err != nil {
@@ -292,13 +334,47 @@ func readVM(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting VM status: %#v", err)
 	}
 
-	// Check that the VM is powered off, and turn off if not.
-	if status == types.VAppStatuses[4] {
-		d.Set("power_on", true)
+	d.Set("power_on", status == types.VAppStatuses[4])
+
+	if vappLink := vm.VM.Link.ForType(types.MimeVApp, types.RelUp); vappLink != nil {
+		d.Set("vapp_href", vappLink.HREF)
+	}
+
+	if vm.VM.StorageProfile != nil {
+		d.Set("storage_profile", vm.VM.StorageProfile.Name)
+	}
+
+	if customization := vm.VM.GuestCustomizationSection; customization != nil {
+		d.Set("initscript", customization.CustomizationScript)
+		d.Set("admin_password_enabled", customization.AdminPasswordEnabled)
+		d.Set("admin_password_auto", customization.AdminPasswordAuto)
+
+		// A generated password is not part of the configuration
+		if !customization.AdminPasswordAuto {
+			d.Set("admin_password", customization.AdminPassword)
+		}
+
+		d.Set("customization", flattenVMCustomization(d, customization))
+	}
+
+	// Read internal disks
+	err = readInternalDisks(d, &vm, meta)
+	if err != nil {
+		return err
+	}
+
+	err = readOvfProperties(d, vm.VM.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	err = readMetadataAttributes(d, vm.VM.HREF, meta)
+	if err != nil {
+		return err
 	}
 
-	// d.Set("vapp_href", vm.VM.VAppParent.HREF)
 	d.Set("name", vm.VM.Name)
+	d.Set("description", vm.VM.Description)
 	d.Set("memory", memoryCount)
 	d.Set("cpus", cpuCount)
 	d.Set("network", readNetworks)
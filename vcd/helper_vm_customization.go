@@ -0,0 +1,265 @@
+package vcd
+
+import (
+	"context"
+	"encoding/xml"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// VirtualMachineCustomizationSubresourceSchema is the schema of the
+// customization block. Only the values vCD generates are computed, a setting
+// removed from the block is reset.
+func VirtualMachineCustomizationSubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"enabled": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  true,
+		},
+		// The name of the VM is used when not set
+		"computer_name": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+		},
+		"change_sid": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"join_domain": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"join_org_domain": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"domain_name": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"domain_user_name": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"domain_user_password": {
+			Type:      schema.TypeString,
+			Optional:  true,
+			Sensitive: true,
+		},
+		"domain_ou": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"admin_password_enabled": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"admin_password_auto": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		// Reported back when it is generated by vCD
+		"admin_password": {
+			Type:      schema.TypeString,
+			Optional:  true,
+			Computed:  true,
+			Sensitive: true,
+		},
+		"reset_password_required": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"admin_auto_logon_enabled": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"admin_auto_logon_count": {
+			Type:     schema.TypeInt,
+			Optional: true,
+			Default:  0,
+		},
+		// Not a vCD setting, a changed customization is only applied on the
+		// next customization of the guest otherwise
+		"customize_on_change": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+	}
+}
+
+// getVMCustomization returns the configured customization block, nil when
+// there is none
+func getVMCustomization(d *schema.ResourceData) map[string]interface{} {
+	customizations := d.Get("customization").([]interface{})
+	if len(customizations) == 0 || customizations[0] == nil {
+		return nil
+	}
+	return customizations[0].(map[string]interface{})
+}
+
+// hasComputerName reports if the computer name is managed by the
+// customization block instead of following the name of the VM. The computer
+// name is read back from vCD, it follows the name of the VM as long as it is
+// equal to it and it is not changed in the configuration.
+func hasComputerName(d *schema.ResourceData) bool {
+	computerName := d.Get("customization.0.computer_name").(string)
+	if computerName == "" {
+		return false
+	}
+	if d.HasChange("customization.0.computer_name") {
+		return true
+	}
+
+	oldName, _ := d.GetChange("name")
+	return computerName != oldName.(string)
+}
+
+// configureVMCustomization applies the customization block to the guest
+// customization section of the VM
+func configureVMCustomization(d *schema.ResourceData, vm *govcd.VM) {
+	customization := getVMCustomization(d)
+	if !d.HasChange("customization") || customization == nil {
+		return
+	}
+
+	log.Printf("[TRACE] (%s) Changing guest customization", d.Get("name").(string))
+
+	if vm.VM.GuestCustomizationSection == nil {
+		vm.VM.GuestCustomizationSection = &types.GuestCustomizationSection{
+			Info: "Specifies Guest OS Customization Settings",
+		}
+	}
+	section := vm.VM.GuestCustomizationSection
+
+	section.Enabled = customization["enabled"].(bool)
+	if hasComputerName(d) {
+		section.ComputerName = customization["computer_name"].(string)
+	}
+	section.ChangeSid = customization["change_sid"].(bool)
+	section.JoinDomainEnabled = customization["join_domain"].(bool)
+	section.UseOrgSettings = customization["join_org_domain"].(bool)
+	section.DomainName = customization["domain_name"].(string)
+	section.DomainUserName = customization["domain_user_name"].(string)
+	section.DomainUserPassword = customization["domain_user_password"].(string)
+	section.MachineObjectOU = customization["domain_ou"].(string)
+	section.AdminPasswordEnabled = customization["admin_password_enabled"].(bool)
+	section.AdminPasswordAuto = customization["admin_password_auto"].(bool)
+	section.ResetPasswordRequired = customization["reset_password_required"].(bool)
+	section.AdminAutoLogonEnabled = customization["admin_auto_logon_enabled"].(bool)
+	section.AdminAutoLogonCount = customization["admin_auto_logon_count"].(int)
+
+	// A generated password must not be sent back
+	section.AdminPassword = ""
+	if !section.AdminPasswordAuto {
+		section.AdminPassword = customization["admin_password"].(string)
+	}
+}
+
+// flattenVMCustomization converts the guest customization section to the
+// customization attribute, the settings which are not part of the section
+// are taken from the current state
+func flattenVMCustomization(d *schema.ResourceData, section *types.GuestCustomizationSection) []map[string]interface{} {
+	customizeOnChange := false
+	domainUserPassword := section.DomainUserPassword
+	adminPassword := section.AdminPassword
+	if customization := getVMCustomization(d); customization != nil {
+		customizeOnChange = customization["customize_on_change"].(bool)
+
+		// The passwords are only returned to users with the right to see them
+		if domainUserPassword == "" {
+			domainUserPassword = customization["domain_user_password"].(string)
+		}
+		if adminPassword == "" {
+			adminPassword = customization["admin_password"].(string)
+		}
+	}
+
+	return []map[string]interface{}{
+		{
+			"enabled":                  section.Enabled,
+			"computer_name":            section.ComputerName,
+			"change_sid":               section.ChangeSid,
+			"join_domain":              section.JoinDomainEnabled,
+			"join_org_domain":          section.UseOrgSettings,
+			"domain_name":              section.DomainName,
+			"domain_user_name":         section.DomainUserName,
+			"domain_user_password":     domainUserPassword,
+			"domain_ou":                section.MachineObjectOU,
+			"admin_password_enabled":   section.AdminPasswordEnabled,
+			"admin_password_auto":      section.AdminPasswordAuto,
+			"admin_password":           adminPassword,
+			"reset_password_required":  section.ResetPasswordRequired,
+			"admin_auto_logon_enabled": section.AdminAutoLogonEnabled,
+			"admin_auto_logon_count":   section.AdminAutoLogonCount,
+			"customize_on_change":      customizeOnChange,
+		},
+	}
+}
+
+// needsRecustomization reports if the changes of the update have to be
+// applied to the guest by a new customization
+func needsRecustomization(d *schema.ResourceData) bool {
+	customization := getVMCustomization(d)
+	if customization == nil || !customization["customize_on_change"].(bool) {
+		return false
+	}
+
+	return d.HasChange("customization") || d.HasChange("initscript") ||
+		(d.HasChange("name") && !hasComputerName(d))
+}
+
+// powerOnWithCustomization deploys and powers on the VM and forces the guest
+// customization to run again, the guest is rebooted by the customization
+func powerOnWithCustomization(ctx context.Context, vm *govcd.VM, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	deployParams := &types.DeployVAppParams{
+		Xmlns:              types.XMLNamespaceXMLNS,
+		PowerOn:            true,
+		ForceCustomization: true,
+	}
+
+	output, err := xml.Marshal(deployParams)
+	if err != nil {
+		return err
+	}
+
+	err = vm.Refresh()
+	if err != nil {
+		return err
+	}
+
+	// A powered off VM can still be deployed, the customization only runs
+	// on the deployment
+	if vm.VM.Deployed {
+		log.Printf("[DEBUG] (%s) Undeploying VM before guest customization", vm.VM.Name)
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return vm.Undeploy(types.UndeployPowerActionPowerOff)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[DEBUG] (%s) Powering on VM with guest customization", vm.VM.Name)
+	return retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return govcd.ExecuteRequest(string(output),
+			vm.VM.HREF+"/action/deploy",
+			"POST",
+			"application/vnd.vmware.vcloud.deployVAppParams+xml",
+			&vcdClient.Client)
+	})
+}
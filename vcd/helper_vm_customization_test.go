@@ -0,0 +1,175 @@
+package vcd
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// testResourceDataUpdate returns the resource data of an update from the
+// state built from the given attributes to the given configuration
+func testResourceDataUpdate(t *testing.T, s map[string]*schema.Schema, state, config map[string]interface{}) *schema.ResourceData {
+	t.Helper()
+
+	current := schema.TestResourceDataRaw(t, s, state)
+	current.SetId("id")
+
+	diff, err := schema.InternalMap(s).Diff(current.State(), terraform.NewResourceConfigRaw(config), nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := schema.InternalMap(s).Data(current.State(), diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestFlattenVMCustomization(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVcdVM().Schema, map[string]interface{}{
+		"customization": []interface{}{
+			map[string]interface{}{
+				"computer_name":        "db-1",
+				"domain_user_password": "secret",
+				"customize_on_change":  true,
+			},
+		},
+	})
+
+	customization := flattenVMCustomization(d, &types.GuestCustomizationSection{
+		Enabled:           true,
+		ComputerName:      "db-1",
+		JoinDomainEnabled: true,
+		DomainName:        "example.com",
+		AdminPasswordAuto: true,
+		AdminPassword:     "generated",
+	})[0]
+
+	expected := map[string]interface{}{
+		"computer_name":        "db-1",
+		"domain_name":          "example.com",
+		"domain_user_password": "secret",
+		"admin_password":       "generated",
+		"customize_on_change":  true,
+	}
+	for key, value := range expected {
+		if customization[key] != value {
+			t.Errorf("expected %s to be %#v, got %#v", key, value, customization[key])
+		}
+	}
+}
+
+func TestHasComputerName(t *testing.T) {
+	customization := func(computerName string) []interface{} {
+		return []interface{}{map[string]interface{}{"computer_name": computerName}}
+	}
+
+	cases := []struct {
+		name     string
+		state    map[string]interface{}
+		config   map[string]interface{}
+		expected bool
+	}{
+		{
+			name:     "follows the renamed VM",
+			state:    map[string]interface{}{"name": "web-1", "customization": customization("web-1")},
+			config:   map[string]interface{}{"name": "web-2"},
+			expected: false,
+		},
+		{
+			name:     "differs from the name",
+			state:    map[string]interface{}{"name": "web-1", "customization": customization("host-1")},
+			config:   map[string]interface{}{"name": "web-2", "customization": customization("host-1")},
+			expected: true,
+		},
+		{
+			name:     "changed with the name",
+			state:    map[string]interface{}{"name": "web-1", "customization": customization("web-1")},
+			config:   map[string]interface{}{"name": "web-2", "customization": customization("host-2")},
+			expected: true,
+		},
+		{
+			name:     "not set",
+			state:    map[string]interface{}{"name": "web-1"},
+			config:   map[string]interface{}{"name": "web-2"},
+			expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		c.state["vapp_href"] = "https://vcd/api/vApp/vapp-1"
+		c.config["vapp_href"] = "https://vcd/api/vApp/vapp-1"
+
+		d := testResourceDataUpdate(t, resourceVcdVM().Schema, c.state, c.config)
+		if hasComputerName(d) != c.expected {
+			t.Errorf("%s: expected %t", c.name, c.expected)
+		}
+	}
+}
+
+func TestVMAdminPasswordConflictsWithCustomization(t *testing.T) {
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":                "web-1",
+		"vapp_href":           "https://vcd/api/vApp/vapp-1",
+		"admin_password_auto": false,
+		"customization": []interface{}{
+			map[string]interface{}{"admin_password_auto": true},
+		},
+	})
+
+	_, errs := resourceVcdVM().Validate(config)
+	if len(errs) == 0 {
+		t.Error("expected admin_password_auto to conflict with customization")
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceVcdVM().Schema, map[string]interface{}{
+		"name":      "web-1",
+		"vapp_href": "https://vcd/api/vApp/vapp-1",
+	})
+	if d.HasChange("admin_password_enabled") || d.HasChange("admin_password_auto") {
+		t.Error("expected the deprecated admin password settings not to be sent when not set")
+	}
+}
+
+func TestVMCustomizationRemovedSettings(t *testing.T) {
+	state := map[string]interface{}{
+		"name": "web",
+		"customization": []interface{}{
+			map[string]interface{}{
+				"computer_name": "web",
+				"join_domain":   true,
+				"domain_name":   "example.com",
+				"change_sid":    true,
+			},
+		},
+	}
+	config := map[string]interface{}{
+		"name": "web",
+		"customization": []interface{}{
+			map[string]interface{}{},
+		},
+	}
+
+	d := testResourceDataUpdate(t, resourceVcdVM().Schema, state, config)
+	vm := &govcd.VM{VM: &types.VM{GuestCustomizationSection: &types.GuestCustomizationSection{
+		ComputerName:      "web",
+		JoinDomainEnabled: true,
+		DomainName:        "example.com",
+		ChangeSid:         true,
+	}}}
+	configureVMCustomization(d, vm)
+
+	// The settings removed from the block are reset, the computer name is
+	// generated from the name of the VM
+	section := vm.VM.GuestCustomizationSection
+	if section.JoinDomainEnabled || section.DomainName != "" || section.ChangeSid {
+		t.Errorf("expected the removed settings to be reset, got %+v", section)
+	}
+	if section.ComputerName != "web" {
+		t.Errorf("expected the computer name to be kept, got %s", section.ComputerName)
+	}
+}
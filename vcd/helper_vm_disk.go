@@ -0,0 +1,590 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/units"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	govcd "github.com/kublr/govcloudair" // Forked from vmware/govcloudair
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// SATA controllers are reported as "other storage device"
+const resourceTypeSATA types.ResourceType = 20
+
+// vmDiskBus describes the controller and the bus type attributes vCD uses for
+// a bus type name of an internal disk. The bus numbers are numbered per
+// controller class.
+type vmDiskBus struct {
+	controller     string
+	controllerType types.ResourceType
+	busType        int
+	// Empty when vCD does not report a sub type for the bus type
+	busSubType string
+}
+
+var vmDiskBuses = map[string]vmDiskBus{
+	"ide":         {"IDE", types.ResourceTypeIDE, 5, ""},
+	"buslogic":    {"SCSI", types.ResourceTypeSCSI, 6, "buslogic"},
+	"parallel":    {"SCSI", types.ResourceTypeSCSI, 6, "lsilogic"},
+	"sas":         {"SCSI", types.ResourceTypeSCSI, 6, "lsilogicsas"},
+	"paravirtual": {"SCSI", types.ResourceTypeSCSI, 6, "VirtualSCSI"},
+	"sata":        {"SATA", resourceTypeSATA, 20, "vmware.sata.ahci"},
+}
+
+// matches reports if the bus type attributes of a disk, or the resource type
+// and sub type of a controller, are the ones of the bus
+func (bus vmDiskBus) matches(busSubType string) bool {
+	return bus.busSubType == "" || bus.busSubType == busSubType
+}
+
+// vmDiskController returns the controller class of the given bus type
+// attribute, unknown bus types are returned as is
+func vmDiskController(busType int) string {
+	for _, bus := range vmDiskBuses {
+		if bus.busType == busType {
+			return bus.controller
+		}
+	}
+	return strconv.Itoa(busType)
+}
+
+// vmDiskBusController returns the controller class of a bus type name, the
+// names of unknown combinations start with the bus type attribute
+func vmDiskBusController(name string) string {
+	if bus, ok := vmDiskBuses[name]; ok {
+		return bus.controller
+	}
+	return strings.SplitN(name, "/", 2)[0]
+}
+
+func vmDiskBusTypeNames() []string {
+	names := make([]string, 0, len(vmDiskBuses))
+	for name := range vmDiskBuses {
+		names = append(names, name)
+	}
+	return names
+}
+
+// vmDiskBusTypeName returns the bus type name of the given bus type
+// attributes, unknown combinations are returned as is
+func vmDiskBusTypeName(busType int, busSubType string) string {
+	for name, bus := range vmDiskBuses {
+		if bus.busType == busType && bus.matches(busSubType) {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d/%s", busType, busSubType)
+}
+
+func VirtualMachineInternalDiskSubresourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"bus_type": {
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringInSlice(vmDiskBusTypeNames(), false),
+		},
+		"bus_number": {
+			Type:     schema.TypeInt,
+			Required: true,
+		},
+		"unit_number": {
+			Type:     schema.TypeInt,
+			Required: true,
+		},
+		"size": {
+			Type:             schema.TypeString,
+			Required:         true,
+			ValidateFunc:     ValidateDiskSize(),
+			DiffSuppressFunc: suppressEqualDiskSize,
+		},
+		// The storage profile of the VM is used when not set
+		"storage_profile": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+		},
+		"iops": {
+			Type:     schema.TypeInt,
+			Optional: true,
+			Computed: true,
+		},
+	}
+}
+
+// vmDiskItems is the disks part of the virtual hardware section of a VM, it
+// is read separately because the vendored types do not expose the iops and
+// the independent disk reference of a hard disk item
+type vmDiskItems struct {
+	Item []*vmDiskItem `xml:"Item"`
+}
+
+type vmDiskItem struct {
+	Address         string               `xml:"Address"`
+	AddressOnParent string               `xml:"AddressOnParent"`
+	Description     string               `xml:"Description"`
+	ElementName     string               `xml:"ElementName"`
+	InstanceID      int                  `xml:"InstanceID"`
+	Parent          string               `xml:"Parent"`
+	ResourceSubType string               `xml:"ResourceSubType"`
+	ResourceType    types.ResourceType   `xml:"ResourceType"`
+	HostResource    []vmDiskHostResource `xml:"HostResource"`
+}
+
+type vmDiskHostResource struct {
+	BusType           int    `xml:"busType,attr"`
+	BusSubType        string `xml:"busSubType,attr"`
+	Capacity          int    `xml:"capacity,attr"`
+	StorageProfile    string `xml:"storageProfileHref,attr"`
+	OverrideVmDefault bool   `xml:"storageProfileOverrideVmDefault,attr"`
+	Iops              int    `xml:"iops,attr"`
+	Disk              string `xml:"disk,attr"`
+}
+
+// ovfDiskItems is the write counterpart of vmDiskItems, the namespace
+// prefixes are spelled out like in the vendored OVF types
+type ovfDiskItems struct {
+	XMLName xml.Name       `xml:"RasdItemsList"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	Rasd    string         `xml:"xmlns:rasd,attr"`
+	Vcloud  string         `xml:"xmlns:vcloud,attr"`
+	Type    string         `xml:"type,attr"`
+	Item    []*ovfDiskItem `xml:"Item"`
+}
+
+type ovfDiskItem struct {
+	Address         string               `xml:"rasd:Address,omitempty"`
+	AddressOnParent string               `xml:"rasd:AddressOnParent,omitempty"`
+	Description     string               `xml:"rasd:Description,omitempty"`
+	ElementName     string               `xml:"rasd:ElementName"`
+	HostResource    *ovfDiskHostResource `xml:"rasd:HostResource,omitempty"`
+	InstanceID      int                  `xml:"rasd:InstanceID"`
+	Parent          string               `xml:"rasd:Parent,omitempty"`
+	ResourceSubType string               `xml:"rasd:ResourceSubType,omitempty"`
+	ResourceType    types.ResourceType   `xml:"rasd:ResourceType"`
+}
+
+type ovfDiskHostResource struct {
+	BusType           int    `xml:"vcloud:busType,attr,omitempty"`
+	BusSubType        string `xml:"vcloud:busSubType,attr,omitempty"`
+	Capacity          int    `xml:"vcloud:capacity,attr,omitempty"`
+	StorageProfile    string `xml:"vcloud:storageProfileHref,attr,omitempty"`
+	OverrideVmDefault bool   `xml:"vcloud:storageProfileOverrideVmDefault,attr,omitempty"`
+	Iops              int    `xml:"vcloud:iops,attr,omitempty"`
+	Disk              string `xml:"vcloud:disk,attr,omitempty"`
+}
+
+// internalDisk is the desired state of an internal disk, a zero value of the
+// storage profile or the iops leaves the current value alone
+type internalDisk struct {
+	busType            string
+	busNumber          int
+	unitNumber         int
+	sizeMB             int
+	storageProfileHREF string
+	iops               int
+}
+
+func (d internalDisk) key() string {
+	return internalDiskKey(vmDiskBusController(d.busType), d.busNumber, d.unitNumber)
+}
+
+// internalDiskKey identifies an internal disk by its address, the bus type of
+// a disk cannot be changed without replacing it
+func internalDiskKey(controller string, busNumber, unitNumber int) string {
+	return fmt.Sprintf("%s:%d:%d", controller, busNumber, unitNumber)
+}
+
+func readVMDiskItems(client *govcd.Client, vmHREF string) (*vmDiskItems, error) {
+	items := &vmDiskItems{}
+	err := getXML(client, vmHREF+"/virtualHardwareSection/disks", items)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read disks of vm: vmHREF=%s", vmHREF)
+	}
+	return items, nil
+}
+
+func updateVMDiskItems(client *govcd.Client, vmHREF string, items *ovfDiskItems) (govcd.Task, error) {
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, "PUT", vmHREF+"/virtualHardwareSection/disks",
+		"application/vnd.vmware.vcloud.rasdItemsList+xml", items, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
+
+// findDiskAddress returns the bus and the unit number of a hard disk item, -1
+// is returned for the values which cannot be found
+func findDiskAddress(items *vmDiskItems, item *vmDiskItem) (int, int) {
+	unitNumber, err := strconv.Atoi(item.AddressOnParent)
+	if err != nil {
+		unitNumber = -1
+	}
+
+	busNumber := -1
+	for _, controller := range items.Item {
+		if strconv.Itoa(controller.InstanceID) == item.Parent {
+			if value, err := strconv.Atoi(controller.Address); err == nil {
+				busNumber = value
+			}
+		}
+	}
+
+	return busNumber, unitNumber
+}
+
+// findIndependentDisk returns the hard disk item of an attached independent disk
+func findIndependentDisk(items *vmDiskItems, diskHREF string) *vmDiskItem {
+	for _, item := range items.Item {
+		for _, hostResource := range item.HostResource {
+			if hostResource.Disk == diskHREF {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// internalDiskItems returns the hard disk items which are not independent disks
+func internalDiskItems(items *vmDiskItems) []*vmDiskItem {
+	var disks []*vmDiskItem
+	for _, item := range items.Item {
+		if item.ResourceType == types.ResourceTypeDisk &&
+			len(item.HostResource) == 1 && item.HostResource[0].Disk == "" {
+			disks = append(disks, item)
+		}
+	}
+	return disks
+}
+
+func internalDiskItemKey(items *vmDiskItems, item *vmDiskItem) string {
+	busNumber, unitNumber := findDiskAddress(items, item)
+	return internalDiskKey(vmDiskController(item.HostResource[0].BusType), busNumber, unitNumber)
+}
+
+// flattenInternalDisks converts the internal disks of a VM to the
+// internal_disk attribute, storageProfileName resolves a storage profile HREF
+func flattenInternalDisks(items *vmDiskItems, vmStorageProfile string, storageProfileName func(string) string) []map[string]interface{} {
+	disks := make([]map[string]interface{}, 0)
+	for _, item := range internalDiskItems(items) {
+		hostResource := item.HostResource[0]
+		busNumber, unitNumber := findDiskAddress(items, item)
+
+		storageProfile := vmStorageProfile
+		if hostResource.StorageProfile != "" {
+			storageProfile = storageProfileName(hostResource.StorageProfile)
+		}
+
+		disks = append(disks, map[string]interface{}{
+			"bus_type":        vmDiskBusTypeName(hostResource.BusType, hostResource.BusSubType),
+			"bus_number":      busNumber,
+			"unit_number":     unitNumber,
+			"size":            units.Base2Bytes(int64(hostResource.Capacity) * int64(units.MiB)).String(),
+			"storage_profile": storageProfile,
+			"iops":            hostResource.Iops,
+		})
+	}
+	return disks
+}
+
+// planInternalDisks builds the disks part of the virtual hardware section
+// which has exactly the desired internal disks. Controllers and attached
+// independent disks are kept, the missing controllers are added. Disks cannot
+// be shrunk and their bus type cannot be changed, a disk is not replaced by an
+// empty one.
+func planInternalDisks(items *vmDiskItems, desired []internalDisk) (*ovfDiskItems, error) {
+	planned := &ovfDiskItems{
+		Xmlns:  types.XMLNamespaceXMLNS,
+		Rasd:   types.XMLNamespaceRASD,
+		Vcloud: types.XMLNamespaceVCloud,
+		Type:   "application/vnd.vmware.vcloud.rasdItemsList+xml",
+	}
+
+	desiredByKey := make(map[string]internalDisk, len(desired))
+	for _, disk := range desired {
+		if _, ok := desiredByKey[disk.key()]; ok {
+			return nil, fmt.Errorf("internal disk %s is defined more than once", disk.key())
+		}
+		desiredByKey[disk.key()] = disk
+	}
+
+	// The addresses of the removed disks, a disk added at the same address
+	// with another bus type is most likely a changed bus type
+	removed := make(map[string]string)
+
+	lastInstanceID := 0
+	for _, item := range items.Item {
+		if item.InstanceID > lastInstanceID {
+			lastInstanceID = item.InstanceID
+		}
+
+		isInternalDisk := item.ResourceType == types.ResourceTypeDisk &&
+			len(item.HostResource) == 1 && item.HostResource[0].Disk == ""
+
+		if !isInternalDisk {
+			planned.Item = append(planned.Item, convertVMDiskItem(item))
+			continue
+		}
+
+		key := internalDiskItemKey(items, item)
+		hostResource := item.HostResource[0]
+		busType := vmDiskBusTypeName(hostResource.BusType, hostResource.BusSubType)
+		disk, ok := desiredByKey[key]
+		if !ok {
+			// Removed from the configuration
+			busNumber, unitNumber := findDiskAddress(items, item)
+			removed[fmt.Sprintf("%d:%d", busNumber, unitNumber)] = busType
+			continue
+		}
+		delete(desiredByKey, key)
+
+		if disk.busType != busType {
+			return nil, fmt.Errorf("the bus type of internal disk %s cannot be changed from %s to %s",
+				key, busType, disk.busType)
+		}
+
+		plannedItem := convertVMDiskItem(item)
+		if disk.sizeMB < plannedItem.HostResource.Capacity {
+			return nil, fmt.Errorf("internal disk %s cannot be shrunk from %dMB to %dMB",
+				key, plannedItem.HostResource.Capacity, disk.sizeMB)
+		}
+		plannedItem.HostResource.Capacity = disk.sizeMB
+		if disk.storageProfileHREF != "" {
+			plannedItem.HostResource.StorageProfile = disk.storageProfileHREF
+			plannedItem.HostResource.OverrideVmDefault = true
+		}
+		if disk.iops > 0 {
+			plannedItem.HostResource.Iops = disk.iops
+		}
+
+		planned.Item = append(planned.Item, plannedItem)
+	}
+
+	for _, disk := range desired {
+		if _, ok := desiredByKey[disk.key()]; !ok {
+			continue
+		}
+		if busType, ok := removed[fmt.Sprintf("%d:%d", disk.busNumber, disk.unitNumber)]; ok {
+			return nil, fmt.Errorf("internal disk %d:%d cannot be replaced by a %s disk, the bus type of a disk "+
+				"cannot be changed. Remove the %s disk first", disk.busNumber, disk.unitNumber, disk.busType, busType)
+		}
+	}
+
+	// The controllers by controller class and bus number
+	controllers := make(map[string]*ovfDiskItem)
+	for _, item := range planned.Item {
+		for _, bus := range vmDiskBuses {
+			if item.ResourceType == bus.controllerType {
+				controllers[fmt.Sprintf("%s:%s", bus.controller, item.Address)] = item
+			}
+		}
+	}
+
+	// Add the new disks in the order of the configuration
+	for _, disk := range desired {
+		if _, ok := desiredByKey[disk.key()]; !ok {
+			continue
+		}
+
+		bus := vmDiskBuses[disk.busType]
+
+		// vCD would put a disk without a controller on the first bus
+		controllerKey := fmt.Sprintf("%s:%d", bus.controller, disk.busNumber)
+		controller, ok := controllers[controllerKey]
+		if !ok {
+			lastInstanceID++
+			controller = &ovfDiskItem{
+				Address:         strconv.Itoa(disk.busNumber),
+				ElementName:     fmt.Sprintf("%s Controller %d", bus.controller, disk.busNumber),
+				InstanceID:      lastInstanceID,
+				ResourceSubType: bus.busSubType,
+				ResourceType:    bus.controllerType,
+			}
+			planned.Item = append(planned.Item, controller)
+			controllers[controllerKey] = controller
+		}
+		if !bus.matches(controller.ResourceSubType) {
+			return nil, fmt.Errorf("internal disk %s cannot be a %s disk, the controller of the bus is %s",
+				disk.key(), disk.busType, controller.ResourceSubType)
+		}
+
+		lastInstanceID++
+
+		newItem := &ovfDiskItem{
+			AddressOnParent: strconv.Itoa(disk.unitNumber),
+			ElementName:     fmt.Sprintf("Hard disk %d", lastInstanceID),
+			InstanceID:      lastInstanceID,
+			Parent:          strconv.Itoa(controller.InstanceID),
+			ResourceType:    types.ResourceTypeDisk,
+			HostResource: &ovfDiskHostResource{
+				BusType:    bus.busType,
+				BusSubType: bus.busSubType,
+				Capacity:   disk.sizeMB,
+				Iops:       disk.iops,
+			},
+		}
+		if disk.storageProfileHREF != "" {
+			newItem.HostResource.StorageProfile = disk.storageProfileHREF
+			newItem.HostResource.OverrideVmDefault = true
+		}
+
+		planned.Item = append(planned.Item, newItem)
+	}
+
+	return planned, nil
+}
+
+func convertVMDiskItem(item *vmDiskItem) *ovfDiskItem {
+	converted := &ovfDiskItem{
+		Address:         item.Address,
+		AddressOnParent: item.AddressOnParent,
+		Description:     item.Description,
+		ElementName:     item.ElementName,
+		InstanceID:      item.InstanceID,
+		Parent:          item.Parent,
+		ResourceSubType: item.ResourceSubType,
+		ResourceType:    item.ResourceType,
+	}
+
+	if len(item.HostResource) > 0 {
+		hostResource := item.HostResource[0]
+		converted.HostResource = &ovfDiskHostResource{
+			BusType:           hostResource.BusType,
+			BusSubType:        hostResource.BusSubType,
+			Capacity:          hostResource.Capacity,
+			StorageProfile:    hostResource.StorageProfile,
+			OverrideVmDefault: hostResource.OverrideVmDefault,
+			Iops:              hostResource.Iops,
+			Disk:              hostResource.Disk,
+		}
+	}
+
+	return converted
+}
+
+// expandInternalDisks reads the internal_disk blocks of the configuration
+func expandInternalDisks(d *schema.ResourceData, vcdClient *VCDClient) ([]internalDisk, error) {
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []internalDisk
+	for _, value := range d.Get("internal_disk").([]interface{}) {
+		disk := value.(map[string]interface{})
+
+		size, err := units.ParseBase2Bytes(disk["size"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("wrong disk size '%s'", disk["size"].(string))
+		}
+
+		storageProfileHREF := ""
+		if storageProfileName := disk["storage_profile"].(string); storageProfileName != "" {
+			storageProfile, err := vdc.FindStorageProfileReference(storageProfileName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot find storage profile: name=%s", storageProfileName)
+			}
+			storageProfileHREF = storageProfile.HREF
+		}
+
+		disks = append(disks, internalDisk{
+			busType:            disk["bus_type"].(string),
+			busNumber:          disk["bus_number"].(int),
+			unitNumber:         disk["unit_number"].(int),
+			sizeMB:             int(size / units.MiB),
+			storageProfileHREF: storageProfileHREF,
+			iops:               disk["iops"].(int),
+		})
+	}
+	return disks, nil
+}
+
+// configureInternalDisks applies the internal_disk blocks, the template disks
+// are left alone when no block is given
+func configureInternalDisks(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	if !d.HasChange("internal_disk") || len(d.Get("internal_disk").([]interface{})) == 0 {
+		return nil
+	}
+
+	desired, err := expandInternalDisks(d, vcdClient)
+	if err != nil {
+		return err
+	}
+
+	items, err := readVMDiskItems(&vcdClient.Client, vm.VM.HREF)
+	if err != nil {
+		return err
+	}
+
+	planned, err := planInternalDisks(items, desired)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := vcdClient.changeContext(d, false)
+	defer cancel()
+
+	return retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return updateVMDiskItems(&vcdClient.Client, vm.VM.HREF, planned)
+	})
+}
+
+// readInternalDisks sets the internal_disk attribute, the disks already in
+// the state keep their order
+func readInternalDisks(d *schema.ResourceData, vm *govcd.VM, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	items, err := readVMDiskItems(&vcdClient.Client, vm.VM.HREF)
+	if err != nil {
+		return err
+	}
+
+	vmStorageProfile := ""
+	if vm.VM.StorageProfile != nil {
+		vmStorageProfile = vm.VM.StorageProfile.Name
+	}
+
+	readDisks := flattenInternalDisks(items, vmStorageProfile, func(href string) string {
+		return findStorageProfileName(vdc, href)
+	})
+
+	return d.Set("internal_disk", orderInternalDisks(d.Get("internal_disk").([]interface{}), readDisks))
+}
+
+func orderInternalDisks(stateDisks []interface{}, readDisks []map[string]interface{}) []map[string]interface{} {
+	diskKey := func(disk map[string]interface{}) string {
+		return internalDiskKey(vmDiskBusController(disk["bus_type"].(string)), disk["bus_number"].(int), disk["unit_number"].(int))
+	}
+
+	ordered := make([]map[string]interface{}, 0, len(readDisks))
+	added := make(map[string]bool, len(readDisks))
+	for _, value := range stateDisks {
+		key := diskKey(value.(map[string]interface{}))
+		for _, disk := range readDisks {
+			if diskKey(disk) == key && !added[key] {
+				ordered = append(ordered, disk)
+				added[key] = true
+			}
+		}
+	}
+	for _, disk := range readDisks {
+		if !added[diskKey(disk)] {
+			ordered = append(ordered, disk)
+		}
+	}
+
+	return ordered
+}
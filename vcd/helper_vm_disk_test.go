@@ -0,0 +1,150 @@
+package vcd
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFindIndependentDiskAddress(t *testing.T) {
+	items := testReadVMDiskItems(t)
+
+	item := findIndependentDisk(items, "https://vcd.example.com/api/disk/1234")
+	if item == nil {
+		t.Fatalf("expected the independent disk to be found")
+	}
+
+	busNumber, unitNumber := findDiskAddress(items, item)
+	if busNumber != 1 || unitNumber != 3 {
+		t.Errorf("expected bus 1 and unit 3, got bus %d and unit %d", busNumber, unitNumber)
+	}
+
+	if item := findIndependentDisk(items, "https://vcd.example.com/api/disk/5678"); item != nil {
+		t.Errorf("expected a detached disk not to be found, got %#v", item)
+	}
+}
+
+func TestFlattenInternalDisks(t *testing.T) {
+	items := testReadVMDiskItems(t)
+
+	disks := flattenInternalDisks(items, "Silver", func(href string) string { return href })
+	if len(disks) != 1 {
+		t.Fatalf("expected only the internal disk, got %#v", disks)
+	}
+
+	expected := map[string]interface{}{
+		"bus_type":        "parallel",
+		"bus_number":      0,
+		"unit_number":     0,
+		"size":            "16GiB",
+		"storage_profile": "Silver",
+		"iops":            0,
+	}
+	if !reflect.DeepEqual(disks[0], expected) {
+		t.Errorf("expected %#v, got %#v", expected, disks[0])
+	}
+}
+
+func TestPlanInternalDisks(t *testing.T) {
+	items := testReadVMDiskItems(t)
+
+	planned, err := planInternalDisks(items, []internalDisk{
+		{busType: "parallel", busNumber: 0, unitNumber: 0, sizeMB: 32768, iops: 500},
+		{busType: "parallel", busNumber: 0, unitNumber: 1, sizeMB: 1024},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Two controllers, the independent disk, the resized and the new disk
+	if len(planned.Item) != 5 {
+		t.Fatalf("expected 5 items, got %d", len(planned.Item))
+	}
+
+	resized := planned.Item[2]
+	if resized.InstanceID != 2000 || resized.HostResource.Capacity != 32768 || resized.HostResource.Iops != 500 {
+		t.Errorf("expected the boot disk to be resized, got %#v", resized.HostResource)
+	}
+
+	independent := planned.Item[3]
+	if independent.HostResource.Disk != "https://vcd.example.com/api/disk/1234" {
+		t.Errorf("expected the independent disk to be kept, got %#v", independent.HostResource)
+	}
+
+	added := planned.Item[4]
+	if added.InstanceID != 2017 || added.Parent != "2" || added.AddressOnParent != "1" ||
+		added.HostResource.Capacity != 1024 || added.HostResource.BusSubType != "lsilogic" {
+		t.Errorf("expected a new disk on the first controller, got %#v %#v", added, added.HostResource)
+	}
+
+	// The missing controllers are added, the new disks of a bus share them
+	planned, err = planInternalDisks(items, []internalDisk{
+		{busType: "parallel", busNumber: 0, unitNumber: 0, sizeMB: 16384},
+		{busType: "sata", busNumber: 0, unitNumber: 0, sizeMB: 1024},
+		{busType: "sata", busNumber: 0, unitNumber: 1, sizeMB: 1024},
+		{busType: "paravirtual", busNumber: 2, unitNumber: 0, sizeMB: 1024},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(planned.Item) != 9 {
+		t.Fatalf("expected 9 items, got %d", len(planned.Item))
+	}
+	sataController, sataDisk, sataDisk2 := planned.Item[4], planned.Item[5], planned.Item[6]
+	if sataController.ResourceType != resourceTypeSATA || sataController.Address != "0" || sataController.InstanceID != 2017 ||
+		sataDisk.Parent != "2017" || sataDisk2.Parent != "2017" {
+		t.Errorf("expected the sata disks on a new sata controller, got %#v %#v %#v", sataController, sataDisk, sataDisk2)
+	}
+	scsiController, scsiDisk := planned.Item[7], planned.Item[8]
+	if scsiController.ResourceSubType != "VirtualSCSI" || scsiController.Address != "2" || scsiDisk.Parent != strconv.Itoa(scsiController.InstanceID) {
+		t.Errorf("expected the disk on a new scsi controller of bus 2, got %#v %#v", scsiController, scsiDisk)
+	}
+
+	_, err = planInternalDisks(items, []internalDisk{
+		{busType: "parallel", busNumber: 0, unitNumber: 0, sizeMB: 16384},
+		{busType: "paravirtual", busNumber: 1, unitNumber: 0, sizeMB: 1024},
+	})
+	if err == nil {
+		t.Errorf("expected an error when adding a disk of another bus type to a controller")
+	}
+
+	// Changing the bus type would replace the disk by an empty one
+	for _, busType := range []string{"sata", "paravirtual"} {
+		_, err = planInternalDisks(items, []internalDisk{
+			{busType: busType, busNumber: 0, unitNumber: 0, sizeMB: 16384},
+		})
+		if err == nil || !strings.Contains(err.Error(), "bus type") {
+			t.Errorf("expected an error when changing the bus type of the boot disk to %s, got %v", busType, err)
+		}
+	}
+
+	_, err = planInternalDisks(items, []internalDisk{
+		{busType: "parallel", busNumber: 0, unitNumber: 0, sizeMB: 1024},
+	})
+	if err == nil {
+		t.Errorf("expected an error when shrinking a disk")
+	}
+}
+
+func TestVMDiskBusTypeName(t *testing.T) {
+	cases := []struct {
+		busType    int
+		busSubType string
+		expected   string
+	}{
+		{5, "", "ide"},
+		{5, "ide", "ide"},
+		{5, "PIIX4", "ide"},
+		{6, "lsilogic", "parallel"},
+		{6, "VirtualSCSI", "paravirtual"},
+		{20, "vmware.sata.ahci", "sata"},
+		{6, "unknown", "6/unknown"},
+	}
+
+	for _, c := range cases {
+		if name := vmDiskBusTypeName(c.busType, c.busSubType); name != c.expected {
+			t.Errorf("%d/%s: expected %s, got %s", c.busType, c.busSubType, c.expected, name)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package vcd
+
+import (
+	"testing"
+)
+
+func TestNeedsVMReconfiguration(t *testing.T) {
+	state := map[string]interface{}{
+		"name":     "web",
+		"memory":   1024,
+		"cpus":     1,
+		"power_on": true,
+		"metadata": map[string]interface{}{"team": "web"},
+	}
+
+	cases := []struct {
+		config   map[string]interface{}
+		expected bool
+	}{
+		{config: map[string]interface{}{"memory": 2048}, expected: true},
+		{config: map[string]interface{}{"name": "db"}, expected: true},
+		{config: map[string]interface{}{"metadata": map[string]interface{}{"team": "db"}}},
+		{config: map[string]interface{}{"ovf_properties": map[string]interface{}{"hostname": "web-1"}}},
+		{config: map[string]interface{}{"power_on": false}},
+	}
+
+	for _, c := range cases {
+		config := map[string]interface{}{}
+		for key, value := range state {
+			config[key] = value
+		}
+		for key, value := range c.config {
+			config[key] = value
+		}
+
+		d := testResourceDataUpdate(t, resourceVcdVM().Schema, state, config)
+		if actual := needsVMReconfiguration(d); actual != c.expected {
+			t.Errorf("%v: expected %t, got %t", c.config, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package vcd
+
+import (
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+// ipsecVpnService is the IPsec VPN service of an edge gateway. The vendored
+// tunnel type drops the IsEnabled flag of a disabled tunnel and always sends
+// a local peer.
+type ipsecVpnService struct {
+	IsEnabled bool                           `xml:"IsEnabled"`
+	Endpoint  *types.GatewayIpsecVpnEndpoint `xml:"Endpoint,omitempty"`
+	Tunnel    []*ipsecVpnTunnel              `xml:"Tunnel,omitempty"`
+}
+
+type ipsecVpnTunnel struct {
+	Name                   string                        `xml:"Name"`
+	Description            string                        `xml:"Description,omitempty"`
+	IpsecVpnThirdPartyPeer *types.IpsecVpnThirdPartyPeer `xml:"IpsecVpnThirdPartyPeer,omitempty"`
+	IpsecVpnLocalPeer      *types.IpsecVpnLocalPeer      `xml:"IpsecVpnLocalPeer,omitempty"`
+	PeerIPAddress          string                        `xml:"PeerIpAddress"`
+	PeerID                 string                        `xml:"PeerId"`
+	LocalIPAddress         string                        `xml:"LocalIpAddress"`
+	LocalID                string                        `xml:"LocalId"`
+	LocalSubnet            []*types.IpsecVpnSubnet       `xml:"LocalSubnet"`
+	PeerSubnet             []*types.IpsecVpnSubnet       `xml:"PeerSubnet"`
+	SharedSecret           string                        `xml:"SharedSecret,omitempty"`
+	SharedSecretEncrypted  bool                          `xml:"SharedSecretEncrypted,omitempty"`
+	EncryptionProtocol     string                        `xml:"EncryptionProtocol"`
+	Mtu                    int                           `xml:"Mtu"`
+	IsEnabled              bool                          `xml:"IsEnabled"`
+	IsOperational          bool                          `xml:"IsOperational,omitempty"`
+	ErrorDetails           string                        `xml:"ErrorDetails,omitempty"`
+}
+
+func findVpnTunnel(tunnels []*ipsecVpnTunnel, name string) *ipsecVpnTunnel {
+	for _, tunnel := range tunnels {
+		if tunnel.Name == name {
+			return tunnel
+		}
+	}
+	return nil
+}
+
+// setVpnTunnel replaces the tunnel with the given name with the given tunnel,
+// the tunnel is added when there is none and removed when the given tunnel is
+// nil. The state of the other tunnels is cleared as vCD only reports it.
+func setVpnTunnel(tunnels []*ipsecVpnTunnel, name string, tunnel *ipsecVpnTunnel) []*ipsecVpnTunnel {
+	updated := make([]*ipsecVpnTunnel, 0, len(tunnels)+1)
+	found := false
+	for _, existing := range tunnels {
+		if existing.Name == name {
+			if tunnel != nil && !found {
+				updated = append(updated, tunnel)
+			}
+			found = true
+			continue
+		}
+		existing.IsOperational = false
+		existing.ErrorDetails = ""
+		updated = append(updated, existing)
+	}
+
+	if tunnel != nil && !found {
+		updated = append(updated, tunnel)
+	}
+
+	return updated
+}
+
+func expandVpnSubnets(configured []interface{}, prefix string) []*types.IpsecVpnSubnet {
+	subnets := make([]*types.IpsecVpnSubnet, 0, len(configured))
+	for _, raw := range configured {
+		subnet := raw.(map[string]interface{})
+		subnets = append(subnets, &types.IpsecVpnSubnet{
+			Name:    subnet[prefix+"_subnet_name"].(string),
+			Gateway: subnet[prefix+"_subnet_gateway"].(string),
+			Netmask: subnet[prefix+"_subnet_mask"].(string),
+		})
+	}
+	return subnets
+}
+
+func flattenVpnSubnets(subnets []*types.IpsecVpnSubnet, prefix string) []interface{} {
+	flattened := make([]interface{}, 0, len(subnets))
+	for _, subnet := range subnets {
+		flattened = append(flattened, map[string]interface{}{
+			prefix + "_subnet_name":    subnet.Name,
+			prefix + "_subnet_gateway": subnet.Gateway,
+			prefix + "_subnet_mask":    subnet.Netmask,
+		})
+	}
+	return flattened
+}
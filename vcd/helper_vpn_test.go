@@ -0,0 +1,60 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindVpnTunnel(t *testing.T) {
+	tunnels := testReadVpnTunnels(t)
+
+	tunnel := findVpnTunnel(tunnels, "west")
+	if tunnel == nil || tunnel.IpsecVpnThirdPartyPeer == nil || tunnel.IpsecVpnThirdPartyPeer.PeerID != "peer" {
+		t.Errorf("expected tunnel west with a third party peer, got %#v", tunnel)
+	}
+	if tunnel := findVpnTunnel(tunnels, "north"); tunnel != nil {
+		t.Errorf("expected no tunnel, got %#v", tunnel)
+	}
+}
+
+func TestSetVpnTunnel(t *testing.T) {
+	cases := []struct {
+		name     string
+		tunnel   string
+		update   *ipsecVpnTunnel
+		expected []string
+	}{
+		{"add", "north", &ipsecVpnTunnel{Name: "north"}, []string{"east", "west", "north"}},
+		{"update", "east", &ipsecVpnTunnel{Name: "east", Mtu: 1400}, []string{"east", "west"}},
+		{"remove", "east", nil, []string{"west"}},
+	}
+
+	for _, c := range cases {
+		updated := setVpnTunnel(testReadVpnTunnels(t), c.tunnel, c.update)
+		names := make([]string, 0, len(updated))
+		for _, tunnel := range updated {
+			names = append(names, tunnel.Name)
+			if tunnel.IsOperational || tunnel.ErrorDetails != "" {
+				t.Errorf("%s: expected the state of tunnel %s to be cleared", c.name, tunnel.Name)
+			}
+		}
+		if !reflect.DeepEqual(names, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, names)
+		}
+		if c.update != nil && findVpnTunnel(updated, c.tunnel) != c.update {
+			t.Errorf("%s: expected tunnel %s to be replaced", c.name, c.tunnel)
+		}
+	}
+}
+
+func TestMarshalDisabledVpnTunnel(t *testing.T) {
+	payload, err := xml.Marshal(testReadVpnTunnels(t)[0])
+	if err != nil {
+		t.Fatalf("cannot encode tunnel: %v", err)
+	}
+	if !strings.Contains(string(payload), "<IsEnabled>false</IsEnabled>") {
+		t.Errorf("expected the disabled flag to be sent, got %s", payload)
+	}
+}
@@ -1,29 +1,57 @@
 package vcd
 
 import (
+	"context"
+
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/kublr/govcloudair/types/v56"
 )
 
 // Provider returns a terraform.ResourceProvider.
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
+			"auth_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("VCD_AUTH_TYPE", authTypePassword),
+				Description:  "The authentication type, one of password, token or api_token.",
+				ValidateFunc: validation.StringInSlice([]string{authTypePassword, authTypeToken, authTypeAPIToken}, false),
+			},
+
 			"user": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("VCD_USER", nil),
 				Description: "The user name for vcd API operations.",
 			},
 
 			"password": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("VCD_PASSWORD", nil),
 				Description: "The user password for vcd API operations.",
 			},
 
+			"token": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("VCD_TOKEN", nil),
+				Description: "The session token of the token authentication.",
+			},
+
+			"api_token": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("VCD_API_TOKEN", nil),
+				Description: "The API token of the api_token authentication.",
+			},
+
 			"org": &schema.Schema{
 				Type:        schema.TypeString,
 				Required:    true,
@@ -51,11 +79,14 @@ func Provider() terraform.ResourceProvider {
 				Deprecated: "Deprecated. Use max_retry_timeout instead.",
 			},
 
+			// The retries are bounded by the timeouts of the resources only
+			// when it is not set
 			"max_retry_timeout": &schema.Schema{
 				Type:        schema.TypeInt,
 				Optional:    true,
-				DefaultFunc: schema.EnvDefaultFunc("VCD_MAX_RETRY_TIMEOUT", 60),
-				Description: "Max num seconds to wait for successful response when operating on resources within vCloud (defaults to 60)",
+				DefaultFunc: schema.EnvDefaultFunc("VCD_MAX_RETRY_TIMEOUT", nil),
+				Description: "Max num seconds to retry failed operations on resources within vCloud",
+				Deprecated:  "Deprecated. Use the timeouts blocks of the resources instead.",
 			},
 
 			"allow_unverified_ssl": &schema.Schema{
@@ -74,22 +105,56 @@ func Provider() terraform.ResourceProvider {
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"vcd_network":         resourceVcdNetwork(),
-			"vcd_vapp":            resourceVcdVApp(),
-			"vcd_firewall_rules":  resourceVcdFirewallRules(),
-			"vcd_dnat":            resourceVcdDNAT(),
-			"vcd_snat":            resourceVcdSNAT(),
-			"vcd_edgegateway_vpn": resourceVcdEdgeGatewayVpn(),
-			"vcd_vm":              resourceVcdVM(),
-			"vcd_catalog":         resourceVcdCatalog(),
-			"vcd_disk":            resourceVcdDisk(),
+			"vcd_network":                  resourceVcdNetwork(),
+			"vcd_vapp":                     resourceVcdVApp(),
+			"vcd_firewall_rules":           resourceVcdFirewallRules(),
+			"vcd_dnat":                     resourceVcdDNAT(),
+			"vcd_snat":                     resourceVcdSNAT(),
+			"vcd_edgegateway_vpn":          resourceVcdEdgeGatewayVpn(),
+			"vcd_vm":                       resourceVcdVM(),
+			"vcd_catalog":                  resourceVcdCatalog(),
+			"vcd_disk":                     resourceVcdDisk(),
+			"vcd_disk_attachment":          resourceVcdDiskAttachment(),
+			"vcd_vm_snapshot":              resourceVcdVMSnapshot(),
+			"vcd_edgegateway_static_route": resourceVcdEdgeGatewayStaticRoute(),
+			"vcd_edgegateway_dhcp_pool":    resourceVcdEdgeGatewayDhcpPool(),
+			"vcd_lb_pool":                  resourceVcdLBPool(),
+			"vcd_lb_virtual_server":        resourceVcdLBVirtualServer(),
+			"vcd_firewall_rule":            resourceVcdFirewallRule(),
+			"vcd_nat_1to1":                 resourceVcdNat1to1(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"vcd_org":              dataSourceVcdOrg(),
+			"vcd_vdc":              dataSourceVcdVdc(),
+			"vcd_catalog":          dataSourceVcdCatalog(),
+			"vcd_catalog_item":     dataSourceVcdCatalogItem(),
+			"vcd_vapp_template":    dataSourceVcdVAppTemplate(),
+			"vcd_network":          dataSourceVcdNetwork(),
+			"vcd_edge_gateway":     dataSourceVcdEdgeGateway(),
+			"vcd_vapp":             dataSourceVcdVApp(),
+			"vcd_vm":               dataSourceVcdVM(),
+			"vcd_disk":             dataSourceVcdDisk(),
+			"vcd_storage_profile":  dataSourceVcdStorageProfile(),
+			"vcd_vms":              dataSourceVcdVMs(),
+			"vcd_vapps":            dataSourceVcdVApps(),
+			"vcd_networks":         dataSourceVcdNetworks(),
+			"vcd_catalog_items":    dataSourceVcdCatalogItems(),
+			"vcd_storage_profiles": dataSourceVcdStorageProfiles(),
+			"vcd_edge_gateways":    dataSourceVcdEdgeGateways(),
 		},
+	}
 
-		ConfigureFunc: providerConfigure,
+	provider.ConfigureFunc = func(d *schema.ResourceData) (interface{}, error) {
+		return providerConfigure(d, provider.StopContext())
 	}
+
+	return provider
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+// providerConfigure sets up the client, the waits for vCD tasks end with
+// stopContext when Terraform is interrupted
+func providerConfigure(d *schema.ResourceData, stopContext context.Context) (interface{}, error) {
 	maxRetryTimeout := d.Get("max_retry_timeout").(int)
 
 	// TODO: Deprecated, remove in next major release
@@ -98,8 +163,11 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	}
 
 	config := Config{
+		AuthType:        d.Get("auth_type").(string),
 		User:            d.Get("user").(string),
 		Password:        d.Get("password").(string),
+		Token:           d.Get("token").(string),
+		APIToken:        d.Get("api_token").(string),
 		Org:             d.Get("org").(string),
 		Href:            d.Get("url").(string),
 		VDC:             d.Get("vdc").(string),
@@ -108,5 +176,11 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		ApiVersion:      d.Get("api_version").(string),
 	}
 
-	return config.Client()
+	vcdClient, err := config.Client()
+	if err != nil {
+		return nil, err
+	}
+	vcdClient.stopContext = stopContext
+
+	return vcdClient, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
+	govcd "github.com/kublr/govcloudair"
 )
 
 var testAccProviders map[string]terraform.ResourceProvider
@@ -48,3 +49,49 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("VCD_VDC must be set for acceptance tests")
 	}
 }
+
+// testAccPreCheckExternalNetwork checks the settings of the tests which use
+// the external network of the edge gateway
+func testAccPreCheckExternalNetwork(t *testing.T) {
+	testAccPreCheck(t)
+
+	if v := os.Getenv("VCD_EXTERNAL_NETWORK"); v == "" {
+		t.Fatal("VCD_EXTERNAL_NETWORK must be set for acceptance tests")
+	}
+	if v := os.Getenv("VCD_EXTERNAL_IP"); v == "" {
+		t.Fatal("VCD_EXTERNAL_IP must be set for acceptance tests")
+	}
+}
+
+// testAccReadEdgeGatewayServices reads the services of the edge gateway of
+// the resource
+func testAccReadEdgeGatewayServices(rs *terraform.ResourceState) (*govcd.EdgeGateway, *edgeGatewayServices, error) {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	edgeGateway, err := conn.OrgVdc.FindEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	services, err := readEdgeGatewayServices(&conn.Client, edgeGateway.EdgeGateway.HREF)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &edgeGateway, services, nil
+}
+
+func TestProviderOrgOverride(t *testing.T) {
+	provider := Provider().(*schema.Provider)
+
+	for name, r := range provider.ResourcesMap {
+		if _, ok := r.Schema["org"]; !ok {
+			t.Errorf("resource %s has no org argument", name)
+		}
+	}
+	for name, r := range provider.DataSourcesMap {
+		if _, ok := r.Schema["org"]; !ok && name != "vcd_org" {
+			t.Errorf("data source %s has no org argument", name)
+		}
+	}
+}
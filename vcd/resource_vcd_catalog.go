@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
 	"github.com/pkg/errors"
 	"log"
 )
@@ -14,8 +15,18 @@ func resourceVcdCatalog() *schema.Resource {
 		Update: resourceVcdCatalogUpdate,
 		Read:   resourceVcdCatalogRead,
 		Delete: resourceVcdCatalogDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(resourceTimeout),
+			Update: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -25,38 +36,44 @@ func resourceVcdCatalog() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"metadata":       MetadataSchema(),
+			"metadata_entry": MetadataEntrySchema(),
 		},
 	}
 }
 
 func resourceVcdCatalogCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	// See if catalog exists
-	catalog, err := vcdClient.Org.FindCatalog(d.Get("name").(string))
+	catalog, err := org.FindCatalog(d.Get("name").(string))
 	log.Printf("[TRACE] Looking for existing catalog, found %#v", catalog)
 	if err != nil {
 		log.Printf("[TRACE] No catalog found, preparing creation")
-		adminOrg, err := vcdClient.GetAdminOrg()
+		adminOrg, err := vcdClient.adminOrgFromResource(d)
 		if err != nil {
 			return errors.Wrap(err, "Unable to create Catalog because error during getting AdminOrg")
 		}
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-			task, err := adminOrg.CreateCatalog(d.Get("name").(string), d.Get("description").(string))
-			if err != nil {
-				return resource.NonRetryableError(fmt.Errorf("error creating Catalog: %#v", err))
-			}
-			return resource.RetryableError(task.WaitTaskCompletion())
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return adminOrg.CreateCatalog(d.Get("name").(string), d.Get("description").(string))
 		})
 
 		if err != nil {
-			return fmt.Errorf("Error completing tasks: %#v", err)
+			return fmt.Errorf("Error completing tasks: %s", err)
 		}
 
-		err = vcdClient.Org.Refresh()
+		err = org.Refresh()
 		if err != nil {
 			return fmt.Errorf("error refreshing org: %#v", err)
 		}
-		catalog, err = vcdClient.Org.FindCatalog(d.Get("name").(string))
+		catalog, err = org.FindCatalog(d.Get("name").(string))
 		if err != nil {
 			return fmt.Errorf("error refreshing just created catalog: %#v", err)
 		}
@@ -64,18 +81,24 @@ func resourceVcdCatalogCreate(d *schema.ResourceData, meta interface{}) error {
 
 	d.SetId(d.Get("name").(string))
 
-	return nil
+	return configureMetadata(d, catalog.Catalog.HREF, meta)
 }
 
 func resourceVcdCatalogUpdate(d *schema.ResourceData, meta interface{}) error {
 	log.Println("[TRACE] resourceVcdCatalogUpdate")
 	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from Org")
-	err := vcdClient.Org.Refresh()
+	err = org.Refresh()
 	if err != nil {
 		return fmt.Errorf("error refreshing Org: %#v", err)
 	}
-	adminOrg, err := vcdClient.GetAdminOrg()
+	adminOrg, err := vcdClient.adminOrgFromResource(d)
 	if err != nil {
 		return errors.Wrap(err, "Unable to update Catalog because error during getting AdminOrg")
 	}
@@ -93,38 +116,61 @@ func resourceVcdCatalogUpdate(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[DEBUG] Unable to updage catalog")
 		return err
 	}
-	return nil
+
+	catalog, err := org.FindCatalog(d.Id())
+	if err != nil {
+		return fmt.Errorf("error finding catalog: %#v", err)
+	}
+	return configureMetadata(d, catalog.Catalog.HREF, meta)
 }
 
 func resourceVcdCatalogRead(d *schema.ResourceData, meta interface{}) error {
 	log.Println("[TRACE] resourceVcdCatalogRead")
 	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from Org")
-	err := vcdClient.Org.Refresh()
+	err = org.Refresh()
 	if err != nil {
 		return fmt.Errorf("error refreshing org: %#v", err)
 	}
 
 	// Should be fetched by ID/HREF
-	catalog, err := vcdClient.Org.FindCatalog(d.Id())
-	if err != nil {
+	catalog, err := org.FindCatalog(d.Id())
+	if isNotFound(err) {
 		log.Printf("[DEBUG] Unable to find catalog. Removing from tfstate")
 		d.SetId("")
 		return nil
 	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read catalog: %s", d.Id())
+	}
+	d.Set("name", catalog.Catalog.Name)
 	d.Set("description", catalog.Catalog.Description)
-	return nil
+	return readMetadataAttributes(d, catalog.Catalog.HREF, meta)
 }
 
 func resourceVcdCatalogDelete(d *schema.ResourceData, meta interface{}) error {
 	log.Println("[TRACE] resourceVcdCatalogDelete")
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	org, err := vcdClient.orgFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from VCD")
-	err := vcdClient.Org.Refresh()
+	err = org.Refresh()
 	if err != nil {
 		return fmt.Errorf("error refreshing org: %#v", err)
 	}
-	adminOrg, err := vcdClient.GetAdminOrg()
+	adminOrg, err := vcdClient.adminOrgFromResource(d)
 	if err != nil {
 		return errors.Wrap(err, "Unable to delete Catalog because error during getting AdminOrg")
 	}
@@ -142,18 +188,21 @@ func resourceVcdCatalogDelete(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 	// Wait until catalog really deleted
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err = retryCall(ctx, func() *resource.RetryError {
 		adminOrg.Refresh()
 		_, err := adminOrg.FindAdminCatalog(d.Id())
-		if err != nil {
+		if isNotFound(err) {
 			return nil
 		}
+		if err != nil {
+			return retryError(err)
+		}
 		log.Printf("[DEBUG] Waiting until catalog %s deleted", d.Id())
 		return resource.RetryableError(errors.Errorf("Catalog %s is not deleted yet", d.Id()))
 	})
 
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return fmt.Errorf("Error completing tasks: %s", err)
 	}
 
 	return nil
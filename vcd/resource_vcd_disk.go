@@ -6,8 +6,8 @@ import (
 	"log"
 
 	"github.com/alecthomas/units"
-	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
 	"github.com/kublr/govcloudair/types/v56"
 )
 
@@ -17,8 +17,19 @@ func resourceVcdDisk() *schema.Resource {
 		Update: resourceVcdDiskUpdate,
 		Read:   resourceVcdDiskRead,
 		Delete: resourceVcdDiskDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(resourceTimeout),
+			Update: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -29,9 +40,10 @@ func resourceVcdDisk() *schema.Resource {
 				Optional: true,
 			},
 			"size": {
-				Type:         schema.TypeString,
-				Required:     true,
-				ValidateFunc: ValidateDiskSize(),
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     ValidateDiskSize(),
+				DiffSuppressFunc: suppressEqualDiskSize,
 			},
 			"iops": {
 				Type:     schema.TypeInt,
@@ -52,17 +64,26 @@ func resourceVcdDisk() *schema.Resource {
 				Optional: true,
 				Computed: true,
 			},
+			"metadata":       MetadataSchema(),
+			"metadata_entry": MetadataEntrySchema(),
 		},
 	}
 }
 
 func resourceVcdDiskCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
 	diskName := d.Get("name").(string)
 
 	// checking if the disk exists
-	foundDisk, err := vcdClient.OrgVdc.FindDiskByName(diskName)
+	foundDisk, err := vdc.FindDiskByName(diskName)
 	if err == nil {
 		return fmt.Errorf("The disk '%s' already exists (HREF: '%s')", diskName, foundDisk.Disk.HREF)
 	}
@@ -85,7 +106,7 @@ func resourceVcdDiskCreate(d *schema.ResourceData, meta interface{}) error {
 
 	storageProfileName := d.Get("storage_profile").(string)
 	if storageProfileName != "" {
-		storageProfile, err := vcdClient.OrgVdc.FindStorageProfileReference(storageProfileName)
+		storageProfile, err := vdc.FindStorageProfileReference(storageProfileName)
 		if err != nil {
 			return err
 		}
@@ -101,29 +122,41 @@ func resourceVcdDiskCreate(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] Create disk '%s'", diskName)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := vcdClient.OrgVdc.CreateDisk(diskCreateParams)
-		if err != nil {
-			return resource.NonRetryableError(fmt.Errorf("Error creating disk '%s': %#v", diskName, err))
-		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return vdc.CreateDisk(diskCreateParams)
 	})
 
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return fmt.Errorf("Error completing tasks: %s", err)
 	}
 
 	d.SetId(d.Get("name").(string))
 
+	disk, err := vdc.FindDiskByName(diskName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find disk: diskName=%s", diskName)
+	}
+
+	err = configureMetadata(d, disk.Disk.HREF, meta)
+	if err != nil {
+		return err
+	}
+
 	return resourceVcdDiskRead(d, meta)
 }
 
 func resourceVcdDiskUpdate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutUpdate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
 	// checking if the disk exists
-	disk, err := vcdClient.OrgVdc.FindDiskByName(d.Id())
+	disk, err := vdc.FindDiskByName(d.Id())
 	if err != nil {
 		log.Printf("Disk '%s' does not exists. removing from tfstate", d.Id())
 		return fmt.Errorf("Disk '%s' does not exists. removing from tfstate", d.Id())
@@ -148,7 +181,7 @@ func resourceVcdDiskUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	storageProfileName := d.Get("storage_profile").(string)
 	if storageProfileName != "" {
-		storageProfile, err := vcdClient.OrgVdc.FindStorageProfileReference(storageProfileName)
+		storageProfile, err := vdc.FindStorageProfileReference(storageProfileName)
 		if err != nil {
 			return err
 		}
@@ -158,19 +191,22 @@ func resourceVcdDiskUpdate(d *schema.ResourceData, meta interface{}) error {
 		diskNewParams.StorageProfile = nil
 	}
 
-	log.Printf("[INFO] Update disk '%s'", diskName)
+	if d.HasChange("name") || d.HasChange("description") || d.HasChange("size") || d.HasChange("iops") ||
+		d.HasChange("bus_type") || d.HasChange("bus_sub_type") || d.HasChange("storage_profile") {
+		log.Printf("[INFO] Update disk '%s'", diskName)
+
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return disk.Update(diskNewParams)
+		})
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := disk.Update(diskNewParams)
 		if err != nil {
-			return resource.NonRetryableError(fmt.Errorf("Error updating disk '%s': %#v", diskName, err))
+			return fmt.Errorf("Error completing tasks: %s", err)
 		}
+	}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
-
+	err = configureMetadata(d, disk.Disk.HREF, meta)
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return err
 	}
 
 	return resourceVcdDiskRead(d, meta)
@@ -179,51 +215,63 @@ func resourceVcdDiskUpdate(d *schema.ResourceData, meta interface{}) error {
 func resourceVcdDiskRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
-	err := vcdClient.OrgVdc.Refresh()
+	vdc, err := vcdClient.vdcFromResource(d)
 	if err != nil {
-		return fmt.Errorf("Error refreshing vdc: %#v", err)
+		return err
 	}
 
-	disk, err := vcdClient.OrgVdc.FindDiskByName(d.Id())
+	err = vdc.Refresh()
 	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	disk, err := vdc.FindDiskByName(d.Id())
+	if isNotFound(err) {
 		log.Printf("Disk '%s' does not exists. removing from tfstate", d.Id())
 		d.SetId("")
 		return nil
 	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read disk: diskName=%s", d.Id())
+	}
 
 	d.Set("name", disk.Disk.Name)
 	d.Set("description", disk.Disk.Description)
-	d.Set("size", disk.Disk.Size)
-	d.Set("iops", disk.Disk.Iops)
+	d.Set("size", units.Base2Bytes(disk.Disk.Size).String())
+	if disk.Disk.Iops != nil {
+		d.Set("iops", *disk.Disk.Iops)
+	}
 	d.Set("bus_type", disk.Disk.BusType)
 	d.Set("bus_sub_type", disk.Disk.BusSubType)
 	if disk.Disk.StorageProfile != nil {
 		d.Set("storage_profile", disk.Disk.StorageProfile.Name)
 	}
 
-	return nil
+	return readMetadataAttributes(d, disk.Disk.HREF, meta)
 }
 
 func resourceVcdDiskDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
-	disk, err := vcdClient.OrgVdc.FindDiskByName(d.Id())
+	disk, err := vdc.FindDiskByName(d.Id())
 	if err != nil {
 		return errors.Wrapf(err, "cannot find disk: diskName=%s", d.Id())
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
 		err := disk.Refresh()
 		if err != nil {
-			return resource.NonRetryableError(errors.Wrapf(err, "cannot refresh disk state: diskName=%s", d.Id()))
+			return govcd.Task{}, errors.Wrapf(err, "cannot refresh disk state: diskName=%s", d.Id())
 		}
 
-		task, err := disk.Delete()
-		if err != nil {
-			return resource.RetryableError(errors.Wrapf(err, "cannot delete disk: diskName=%s", d.Id()))
-		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return disk.Delete()
 	})
 
 	if err != nil {
@@ -233,6 +281,22 @@ func resourceVcdDiskDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// suppressEqualDiskSize hides the diff between different notations of the
+// same size, e.g. 1GB and 1024MB
+func suppressEqualDiskSize(k, old, new string, d *schema.ResourceData) bool {
+	oldSize, err := units.ParseBase2Bytes(old)
+	if err != nil {
+		return false
+	}
+
+	newSize, err := units.ParseBase2Bytes(new)
+	if err != nil {
+		return false
+	}
+
+	return oldSize == newSize
+}
+
 func ValidateDiskSize() schema.SchemaValidateFunc {
 	return func(i interface{}, k string) (s []string, es []error) {
 		v, ok := i.(string)
@@ -0,0 +1,259 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+func resourceVcdDiskAttachment() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdDiskAttachmentCreate,
+		Read:   resourceVcdDiskAttachmentRead,
+		Delete: resourceVcdDiskAttachmentDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+			"disk_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"vm_href": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"bus_number": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"unit_number": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceVcdDiskAttachmentCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	diskName := d.Get("disk_name").(string)
+	vmHREF := d.Get("vm_href").(string)
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	disk, err := vdc.FindDiskByName(diskName)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find disk: diskName=%s", diskName)
+	}
+
+	vm, err := vdc.GetVMByHREF(vmHREF)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find vm: vmHREF=%s", vmHREF)
+	}
+
+	if !hasDiskAttachLink(&vm, types.RelDiskAttach) {
+		return fmt.Errorf("The VM '%s' does not support attaching independent disks", vm.VM.Name)
+	}
+
+	attachParams := &types.DiskAttachOrDetachParams{
+		Disk: &types.Reference{HREF: disk.Disk.HREF},
+	}
+	if busNumber, ok := d.GetOkExists("bus_number"); ok {
+		value := busNumber.(int)
+		attachParams.BusNumber = &value
+	}
+	if unitNumber, ok := d.GetOkExists("unit_number"); ok {
+		value := unitNumber.(int)
+		attachParams.UnitNumber = &value
+	}
+
+	log.Printf("[INFO] Attach disk '%s' to VM '%s'", diskName, vm.VM.Name)
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return vm.AttachDisk(attachParams)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot attach disk: diskName=%s, vmHREF=%s", diskName, vmHREF)
+	}
+
+	d.SetId(diskName)
+
+	return resourceVcdDiskAttachmentRead(d, meta)
+}
+
+func resourceVcdDiskAttachmentRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	disk, err := vdc.FindDiskByName(d.Id())
+	if isNotFound(err) {
+		log.Printf("Disk '%s' does not exists. removing attachment from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read disk: diskName=%s", d.Id())
+	}
+
+	attachedVM, err := disk.AttachedVM()
+	if err != nil {
+		return errors.Wrapf(err, "cannot find attached vm: diskName=%s", d.Id())
+	}
+
+	// The disk was detached out of band
+	if attachedVM == nil {
+		log.Printf("Disk '%s' is not attached to any VM. removing attachment from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	// The disk was moved to another VM out of band, an imported attachment
+	// has no VM yet
+	if vmHREF := d.Get("vm_href").(string); vmHREF != "" && vmHREF != attachedVM.HREF {
+		log.Printf("Disk '%s' is attached to another VM '%s'. removing attachment from tfstate", d.Id(), attachedVM.HREF)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("disk_name", disk.Disk.Name)
+	d.Set("vm_href", attachedVM.HREF)
+
+	busNumber, unitNumber, err := readDiskAddress(&vcdClient.Client, attachedVM.HREF, disk.Disk.HREF)
+	if err != nil {
+		return err
+	}
+	if busNumber >= 0 {
+		d.Set("bus_number", busNumber)
+	}
+	if unitNumber >= 0 {
+		d.Set("unit_number", unitNumber)
+	}
+
+	return nil
+}
+
+func resourceVcdDiskAttachmentDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	disk, err := vdc.FindDiskByName(d.Id())
+	if err != nil {
+		log.Printf("Disk '%s' does not exists. nothing to detach", d.Id())
+		return nil
+	}
+
+	attachedVM, err := disk.AttachedVM()
+	if err != nil {
+		return errors.Wrapf(err, "cannot find attached vm: diskName=%s", d.Id())
+	}
+	if attachedVM == nil {
+		log.Printf("Disk '%s' is already detached", d.Id())
+		return nil
+	}
+	if attachedVM.HREF != d.Get("vm_href").(string) {
+		log.Printf("Disk '%s' is attached to another VM '%s'. nothing to detach", d.Id(), attachedVM.HREF)
+		return nil
+	}
+
+	vm, err := vdc.GetVMByHREF(attachedVM.HREF)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find vm: vmHREF=%s", attachedVM.HREF)
+	}
+
+	if !hasDiskAttachLink(&vm, types.RelDiskDetach) {
+		return fmt.Errorf("The VM '%s' does not support detaching independent disks", vm.VM.Name)
+	}
+
+	detachParams := &types.DiskAttachOrDetachParams{
+		Disk: &types.Reference{HREF: disk.Disk.HREF},
+	}
+
+	log.Printf("[INFO] Detach disk '%s' from VM '%s'", d.Id(), vm.VM.Name)
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return vm.DetachDisk(detachParams)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot detach disk: diskName=%s, vmHREF=%s", d.Id(), attachedVM.HREF)
+	}
+
+	return nil
+}
+
+// hasDiskAttachLink reports if the VM has the attach or detach link, the
+// govcloudair calls do not check it
+func hasDiskAttachLink(vm *govcd.VM, rel string) bool {
+	for _, link := range vm.VM.Link {
+		if link.Rel == rel && link.Type == types.MimeDiskAttachOrDetachParams {
+			return true
+		}
+	}
+	return false
+}
+
+// readDiskAddress returns the bus and the unit number an independent disk is
+// attached at, -1 is returned for the values which cannot be found
+func readDiskAddress(client *govcd.Client, vmHREF, diskHREF string) (int, int, error) {
+	items, err := readVMDiskItems(client, vmHREF)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	item := findIndependentDisk(items, diskHREF)
+	if item == nil {
+		return -1, -1, nil
+	}
+
+	busNumber, unitNumber := findDiskAddress(items, item)
+	return busNumber, unitNumber, nil
+}
@@ -0,0 +1,103 @@
+package vcd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdDiskAttachmentDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_disk_attachment" {
+			continue
+		}
+
+		disk, err := conn.OrgVdc.FindDiskByName(rs.Primary.ID)
+		if err != nil {
+			continue
+		}
+
+		attachedVM, err := disk.AttachedVM()
+		if err != nil {
+			return err
+		}
+		if attachedVM != nil {
+			return fmt.Errorf("Disk %s is still attached to %s", rs.Primary.ID, attachedVM.HREF)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdDiskAttachment_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdDiskAttachmentDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCheckVcdDiskAttachment_basic0,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_disk_attachment.test-attachment", "disk_name", "test-attachment"),
+					resource.TestCheckResourceAttrPair(
+						"vcd_disk_attachment.test-attachment", "vm_href", "vcd_vm.test-vm", "href"),
+					resource.TestCheckResourceAttr(
+						"vcd_disk_attachment.test-attachment", "bus_number", "1"),
+					resource.TestCheckResourceAttr(
+						"vcd_disk_attachment.test-attachment", "unit_number", "0"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_disk_attachment.test-attachment",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: testAccCheckVcdDiskAttachment_basic1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_disk_attachment.test-attachment", "disk_name", "test-attachment"),
+					resource.TestCheckResourceAttr(
+						"vcd_disk_attachment.test-attachment", "bus_number", "1"),
+					resource.TestCheckResourceAttr(
+						"vcd_disk_attachment.test-attachment", "unit_number", "1"),
+				),
+			},
+		},
+	})
+}
+
+var testAccCheckVcdDiskAttachment_basic0 = fmt.Sprintf(`
+%s
+resource "vcd_disk" "test-disk" {
+  name = "test-attachment"
+  size = "1GB"
+}
+
+resource "vcd_disk_attachment" "test-attachment" {
+  disk_name   = "${vcd_disk.test-disk.name}"
+  vm_href     = "${vcd_vm.test-vm.href}"
+  bus_number  = 1
+  unit_number = 0
+}
+`, testAccCheckVcdVm_single)
+
+var testAccCheckVcdDiskAttachment_basic1 = fmt.Sprintf(`
+%s
+resource "vcd_disk" "test-disk" {
+  name = "test-attachment"
+  size = "1GB"
+}
+
+resource "vcd_disk_attachment" "test-attachment" {
+  disk_name   = "${vcd_disk.test-disk.name}"
+  vm_href     = "${vcd_vm.test-vm.href}"
+  bus_number  = 1
+  unit_number = 1
+}
+`, testAccCheckVcdVm_single)
@@ -2,18 +2,34 @@ package vcd
 
 import (
 	"fmt"
+	"log"
+	"strings"
 
-	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/kublr/govcloudair/types/v56"
 )
 
 func resourceVcdDNAT() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVcdDNATCreate,
+		Update: resourceVcdDNATUpdate,
 		Delete: resourceVcdDNATDelete,
 		Read:   resourceVcdDNATRead,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdDNATImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
@@ -23,135 +39,189 @@ func resourceVcdDNAT() *schema.Resource {
 			"external_ip": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"port": &schema.Schema{
-				Type:     schema.TypeInt,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateNatPort,
 			},
 
+			// The port is not translated when not set
 			"translated_port": &schema.Schema{
-				Type:     schema.TypeInt,
-				Optional: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateNatPort,
 			},
 
 			"internal_ip": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+			},
+
+			"protocol": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "tcp",
+				ValidateFunc: validation.StringInSlice([]string{"tcp", "udp", "tcpudp", "icmp", "any"}, false),
+			},
+
+			"icmp_sub_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			// The uplink interface is used when not set
+			"network_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
 			},
 		},
 	}
 }
 
 func resourceVcdDNATCreate(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
-	portString := getPortString(d.Get("port").(int))
-	translatedPortString := portString // default
-	if d.Get("translated_port").(int) > 0 {
-		translatedPortString = getPortString(d.Get("translated_port").(int))
-	}
-
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
-
+	err := changeNatRule(d, meta, func(edgeGateway *types.EdgeGateway) (*types.NatRule, error) {
+		return expandDNATRule(d, edgeGateway)
+	})
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		return err
 	}
 
-	// Creating a loop to offer further protection from the edge gateway erroring
-	// due to being busy eg another person is using another client so wouldn't be
-	// constrained by out lock. If the edge gateway reurns with a busy error, wait
-	// 3 seconds and then try again. Continue until a non-busy error or success
-
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := edgeGateway.AddNATPortMapping("DNAT",
-			d.Get("external_ip").(string),
-			portString,
-			d.Get("internal_ip").(string),
-			translatedPortString)
-		if err != nil {
-			return resource.RetryableError(
-				fmt.Errorf("Error setting DNAT rules: %#v", err))
-		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+	return resourceVcdDNATRead(d, meta)
+}
 
+func resourceVcdDNATUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeNatRule(d, meta, func(edgeGateway *types.EdgeGateway) (*types.NatRule, error) {
+		return expandDNATRule(d, edgeGateway)
+	})
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return err
 	}
 
-	d.SetId(d.Get("external_ip").(string) + ":" + portString + " > " + d.Get("internal_ip").(string) + ":" + translatedPortString)
-	return nil
+	return resourceVcdDNATRead(d, meta)
 }
 
 func resourceVcdDNATRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	e, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
 
+	vdc, err := vcdClient.vdcFromResource(d)
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		return err
 	}
 
-	var found bool
-
-	for _, r := range e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService.NatRule {
-		if r.RuleType == "DNAT" &&
-			r.GatewayNatRule.OriginalIP == d.Get("external_ip").(string) &&
-			r.GatewayNatRule.OriginalPort == getPortString(d.Get("port").(int)) {
-			found = true
-			d.Set("internal_ip", r.GatewayNatRule.TranslatedIP)
-		}
+	e, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	if !found {
+	// The ids of rules created by earlier versions of the provider, and of
+	// rules imported by port, are "ip:port" based
+	rule := findNatRule(edgeGatewayNatRules(e.EdgeGateway), "DNAT", d.Id(), func(r *types.GatewayNatRule) bool {
+		return strings.Contains(d.Id(), ":") &&
+			r.OriginalIP == d.Get("external_ip").(string) &&
+			r.OriginalPort == d.Get("port").(string)
+	})
+	if rule == nil {
+		log.Printf("[DEBUG] DNAT rule %s no longer exists. Removing from tfstate", d.Id())
 		d.SetId("")
+		return nil
 	}
 
+	d.SetId(rule.ID)
+	d.Set("external_ip", rule.GatewayNatRule.OriginalIP)
+	d.Set("port", rule.GatewayNatRule.OriginalPort)
+	d.Set("internal_ip", rule.GatewayNatRule.TranslatedIP)
+	d.Set("translated_port", rule.GatewayNatRule.TranslatedPort)
+	d.Set("protocol", strings.ToLower(rule.GatewayNatRule.Protocol))
+	d.Set("icmp_sub_type", rule.GatewayNatRule.IcmpSubType)
+	d.Set("network_name", natInterfaceName(e.EdgeGateway, rule.GatewayNatRule))
+	d.Set("description", rule.Description)
+	d.Set("enabled", rule.IsEnabled)
+
 	return nil
 }
 
-func resourceVcdDNATDelete(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
-	portString := getPortString(d.Get("port").(int))
-	translatedPortString := portString // default
-	if d.Get("translated_port").(int) > 0 {
-		translatedPortString = getPortString(d.Get("translated_port").(int))
+// resourceVcdDNATImport accepts "edge-gateway/rule-id" or
+// "edge-gateway/external-ip:port"
+func resourceVcdDNATImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<rule-id> or <edge-gateway>/<external-ip>:<port>")
+	if err != nil {
+		return nil, err
 	}
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
+
+	if externalIP, port, err := splitHostPort(parts[1]); err == nil {
+		d.Set("external_ip", externalIP)
+		d.Set("port", getPortString(port))
+	}
 
+	err = resourceVcdDNATRead(d, meta)
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		return nil, err
 	}
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := edgeGateway.RemoveNATPortMapping("DNAT",
-			d.Get("external_ip").(string),
-			portString,
-			d.Get("internal_ip").(string),
-			translatedPortString)
-		if err != nil {
-			return resource.RetryableError(
-				fmt.Errorf("Error setting DNAT rules: %#v", err))
-		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("DNAT rule %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVcdDNATDelete(d *schema.ResourceData, meta interface{}) error {
+	return changeNatRule(d, meta, nil)
+}
+
+func expandDNATRule(d *schema.ResourceData, edgeGateway *types.EdgeGateway) (*types.NatRule, error) {
+	gatewayInterface, err := natInterface(edgeGateway, d.Get("network_name").(string))
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return nil, err
 	}
-	return nil
+
+	// A computed translated port is only kept while the port is unchanged
+	translatedPort := d.Get("translated_port").(string)
+	if translatedPort == "" || (d.HasChange("port") && !d.HasChange("translated_port")) {
+		translatedPort = d.Get("port").(string)
+	}
+
+	icmpSubType := ""
+	if d.Get("protocol").(string) == "icmp" {
+		icmpSubType = d.Get("icmp_sub_type").(string)
+	}
+
+	return &types.NatRule{
+		Description: d.Get("description").(string),
+		RuleType:    "DNAT",
+		IsEnabled:   d.Get("enabled").(bool),
+		GatewayNatRule: &types.GatewayNatRule{
+			Interface:      gatewayInterface,
+			OriginalIP:     d.Get("external_ip").(string),
+			OriginalPort:   d.Get("port").(string),
+			TranslatedIP:   d.Get("internal_ip").(string),
+			TranslatedPort: translatedPort,
+			Protocol:       d.Get("protocol").(string),
+			IcmpSubType:    icmpSubType,
+		},
+	}, nil
 }
@@ -0,0 +1,294 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+func resourceVcdEdgeGatewayDhcpPool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdEdgeGatewayDhcpPoolCreate,
+		Read:   resourceVcdEdgeGatewayDhcpPoolRead,
+		Update: resourceVcdEdgeGatewayDhcpPoolUpdate,
+		Delete: resourceVcdEdgeGatewayDhcpPoolDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdEdgeGatewayDhcpPoolImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+			"edge_gateway": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			// The pools of an edge gateway are identified by their network
+			"network": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"start_address": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: ValidateIPv4(),
+			},
+			"end_address": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: ValidateIPv4(),
+			},
+			"default_lease_time": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  3600,
+			},
+			"max_lease_time": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  7200,
+			},
+		},
+	}
+}
+
+func resourceVcdEdgeGatewayDhcpPoolCreate(d *schema.ResourceData, meta interface{}) error {
+	err := changeDhcpPool(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(d.Get("network").(string))
+
+	return resourceVcdEdgeGatewayDhcpPoolRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayDhcpPoolUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeDhcpPool(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdEdgeGatewayDhcpPoolRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayDhcpPoolDelete(d *schema.ResourceData, meta interface{}) error {
+	return changeDhcpPool(d, meta, true)
+}
+
+func resourceVcdEdgeGatewayDhcpPoolRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	pool := findDhcpPool(edgeGateway.EdgeGateway, d.Id())
+	if pool == nil {
+		log.Printf("[DEBUG] DHCP pool of network %s no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("network", d.Id())
+	d.Set("start_address", pool.LowIPAddress)
+	d.Set("end_address", pool.HighIPAddress)
+	d.Set("default_lease_time", pool.DefaultLeaseTime)
+	d.Set("max_lease_time", pool.MaxLeaseTime)
+
+	return nil
+}
+
+// resourceVcdEdgeGatewayDhcpPoolImport accepts "edge-gateway/network"
+func resourceVcdEdgeGatewayDhcpPoolImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<network>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
+
+	err = resourceVcdEdgeGatewayDhcpPoolRead(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("DHCP pool of network %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// changeDhcpPool adds, updates or removes the pool of the resource, the
+// other pools of the edge gateway are sent back as they are. A pool created
+// outside of Terraform is not taken over.
+func changeDhcpPool(d *schema.ResourceData, meta interface{}, remove bool) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	network := d.Get("network").(string)
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	var pool *types.DhcpPoolService
+	if remove {
+		if findDhcpPool(edgeGateway.EdgeGateway, network) == nil {
+			log.Printf("[DEBUG] DHCP pool of network %s is already removed", network)
+			return nil
+		}
+	} else {
+		pool, err = expandDhcpPool(d, edgeGateway.EdgeGateway)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[INFO] Changing DHCP pool of network %s on edge gateway %s", network, edgeGateway.EdgeGateway.Name)
+
+	ctx, cancel := vcdClient.changeContext(d, remove)
+	defer cancel()
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		// The pools are read again as the edge gateway may have changed
+		// while it was busy
+		err := edgeGateway.Refresh()
+		if err != nil {
+			return govcd.Task{}, errors.Wrapf(err, "cannot read edge gateway: %s", edgeGateway.EdgeGateway.Name)
+		}
+		if d.IsNewResource() && findDhcpPool(edgeGateway.EdgeGateway, network) != nil {
+			return govcd.Task{}, fmt.Errorf("The edge gateway '%s' already has a DHCP pool on the network '%s'", edgeGateway.EdgeGateway.Name, network)
+		}
+
+		dhcpService := &types.GatewayDhcpService{IsEnabled: true}
+		if current := edgeGatewayDhcpService(edgeGateway.EdgeGateway); current != nil {
+			dhcpService.IsEnabled = current.IsEnabled || !remove
+			dhcpService.Pool = current.Pool
+		}
+		dhcpService.Pool = setDhcpPool(dhcpService.Pool, dhcpPoolMatcher(edgeGateway.EdgeGateway, network), pool)
+
+		return configureEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF, &edgeGatewayServices{
+			GatewayDhcpService: dhcpService,
+		})
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot change DHCP pool: network=%s", network)
+	}
+
+	return nil
+}
+
+func expandDhcpPool(d *schema.ResourceData, edgeGateway *types.EdgeGateway) (*types.DhcpPoolService, error) {
+	network := d.Get("network").(string)
+	gatewayInterface := findGatewayInterface(edgeGateway, network)
+	if gatewayInterface == nil {
+		return nil, fmt.Errorf("The edge gateway '%s' has no interface on the network '%s'", edgeGateway.Name, network)
+	}
+
+	return &types.DhcpPoolService{
+		IsEnabled: true,
+		Network: &types.Reference{
+			HREF: gatewayInterface.Network.HREF,
+			Name: gatewayInterface.Network.Name,
+		},
+		DefaultLeaseTime: d.Get("default_lease_time").(int),
+		MaxLeaseTime:     d.Get("max_lease_time").(int),
+		LowIPAddress:     d.Get("start_address").(string),
+		HighIPAddress:    d.Get("end_address").(string),
+	}, nil
+}
+
+func edgeGatewayDhcpService(edgeGateway *types.EdgeGateway) *types.GatewayDhcpService {
+	if edgeGateway.Configuration == nil || edgeGateway.Configuration.EdgeGatewayServiceConfiguration == nil {
+		return nil
+	}
+	return edgeGateway.Configuration.EdgeGatewayServiceConfiguration.GatewayDhcpService
+}
+
+// findDhcpPool returns the DHCP pool of the edge gateway serving the network
+// with the given name
+func findDhcpPool(edgeGateway *types.EdgeGateway, network string) *types.DhcpPoolService {
+	dhcpService := edgeGatewayDhcpService(edgeGateway)
+	if dhcpService == nil {
+		return nil
+	}
+
+	matches := dhcpPoolMatcher(edgeGateway, network)
+	for _, pool := range dhcpService.Pool {
+		if matches(pool) {
+			return pool
+		}
+	}
+	return nil
+}
+
+// dhcpPoolMatcher returns a function matching the DHCP pools of the network
+// with the given name. The pools reference the network by HREF, the name of
+// the reference may not be set.
+func dhcpPoolMatcher(edgeGateway *types.EdgeGateway, network string) func(*types.DhcpPoolService) bool {
+	networkHREF := ""
+	if gatewayInterface := findGatewayInterface(edgeGateway, network); gatewayInterface != nil {
+		networkHREF = gatewayInterface.Network.HREF
+	}
+
+	return func(pool *types.DhcpPoolService) bool {
+		if pool.Network == nil {
+			return false
+		}
+		return (networkHREF != "" && pool.Network.HREF == networkHREF) || pool.Network.Name == network
+	}
+}
+
+// setDhcpPool replaces the matching pools with the given pool, the pool is
+// added when there is none and the pools are removed when it is nil
+func setDhcpPool(pools []*types.DhcpPoolService, matches func(*types.DhcpPoolService) bool, pool *types.DhcpPoolService) []*types.DhcpPoolService {
+	updated := make([]*types.DhcpPoolService, 0, len(pools)+1)
+	for _, existing := range pools {
+		if !matches(existing) {
+			updated = append(updated, existing)
+		}
+	}
+
+	if pool != nil {
+		updated = append(updated, pool)
+	}
+
+	return updated
+}
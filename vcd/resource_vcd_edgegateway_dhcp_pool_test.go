@@ -0,0 +1,91 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdEdgeGatewayDhcpPoolDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_edgegateway_dhcp_pool" {
+			continue
+		}
+
+		edgeGateway, _, err := testAccReadEdgeGatewayServices(rs)
+		if err != nil {
+			return err
+		}
+
+		if findDhcpPool(edgeGateway.EdgeGateway, rs.Primary.ID) != nil {
+			return fmt.Errorf("DHCP pool on the network %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdEdgeGatewayDhcpPool_Basic(t *testing.T) {
+	edgeGateway := os.Getenv("VCD_EDGE_GATEWAY")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdEdgeGatewayDhcpPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayDhcpPool_basic, edgeGateway, "10.254.0.200", 3600),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp_pool.test-pool", "network", "terraform-test-dhcp"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp_pool.test-pool", "start_address", "10.254.0.101"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp_pool.test-pool", "end_address", "10.254.0.200"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp_pool.test-pool", "default_lease_time", "3600"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_edgegateway_dhcp_pool.test-pool",
+				ImportState:       true,
+				ImportStateId:     edgeGateway + "/terraform-test-dhcp",
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayDhcpPool_basic, edgeGateway, "10.254.0.150", 7200),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp_pool.test-pool", "end_address", "10.254.0.150"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp_pool.test-pool", "default_lease_time", "7200"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdEdgeGatewayDhcpPool_basic = `
+resource "vcd_network" "test-network" {
+  name         = "terraform-test-dhcp"
+  edge_gateway = "%s"
+  gateway      = "10.254.0.1"
+
+  static_ip_pool {
+    start_address = "10.254.0.2"
+    end_address   = "10.254.0.100"
+  }
+}
+
+resource "vcd_edgegateway_dhcp_pool" "test-pool" {
+  edge_gateway       = "${vcd_network.test-network.edge_gateway}"
+  network            = "${vcd_network.test-network.name}"
+  start_address      = "10.254.0.101"
+  end_address        = "%s"
+  default_lease_time = %d
+  max_lease_time     = 7200
+}
+`
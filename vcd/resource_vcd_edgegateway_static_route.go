@@ -0,0 +1,288 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+func resourceVcdEdgeGatewayStaticRoute() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdEdgeGatewayStaticRouteCreate,
+		Read:   resourceVcdEdgeGatewayStaticRouteRead,
+		Update: resourceVcdEdgeGatewayStaticRouteUpdate,
+		Delete: resourceVcdEdgeGatewayStaticRouteDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdEdgeGatewayStaticRouteImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+			"edge_gateway": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			// The routes of an edge gateway are identified by their network
+			"network": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"next_hop_ip": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: ValidateIPv4(),
+			},
+			"interface": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			// The name of the route in vCD, the network is used when not set
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVcdEdgeGatewayStaticRouteCreate(d *schema.ResourceData, meta interface{}) error {
+	err := changeStaticRoute(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(d.Get("network").(string))
+
+	return resourceVcdEdgeGatewayStaticRouteRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayStaticRouteUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeStaticRoute(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdEdgeGatewayStaticRouteRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayStaticRouteDelete(d *schema.ResourceData, meta interface{}) error {
+	return changeStaticRoute(d, meta, true)
+}
+
+func resourceVcdEdgeGatewayStaticRouteRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	services, err := readEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF)
+	if err != nil {
+		return err
+	}
+
+	var route *staticRoute
+	if services.StaticRoutingService != nil {
+		route = findStaticRoute(services.StaticRoutingService.StaticRoute, d.Id())
+	}
+	if route == nil {
+		log.Printf("[DEBUG] Static route %s no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("network", route.Network)
+	d.Set("next_hop_ip", route.NextHopIP)
+	d.Set("description", route.Name)
+
+	if route.GatewayInterface != nil {
+		interfaceName := route.GatewayInterface.Name
+		if gatewayInterface := findGatewayInterfaceByHREF(edgeGateway.EdgeGateway, route.GatewayInterface.HREF); gatewayInterface != nil {
+			interfaceName = gatewayInterface.Network.Name
+		}
+		d.Set("interface", interfaceName)
+	}
+
+	return nil
+}
+
+// resourceVcdEdgeGatewayStaticRouteImport accepts "edge-gateway/network",
+// e.g. "edge/10.10.0.0/16"
+func resourceVcdEdgeGatewayStaticRouteImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<network>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
+
+	err = resourceVcdEdgeGatewayStaticRouteRead(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("static route %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// changeStaticRoute adds, updates or removes the route of the resource, the
+// other routes of the edge gateway are sent back as they are. A route created
+// outside of Terraform is not taken over.
+func changeStaticRoute(d *schema.ResourceData, meta interface{}, remove bool) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	network := d.Get("network").(string)
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	var route *staticRoute
+	if remove {
+		services, err := readEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF)
+		if err != nil {
+			return err
+		}
+		if services.StaticRoutingService == nil || findStaticRoute(services.StaticRoutingService.StaticRoute, network) == nil {
+			log.Printf("[DEBUG] Static route %s is already removed", network)
+			return nil
+		}
+	} else {
+		route, err = expandStaticRoute(d, edgeGateway.EdgeGateway)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[INFO] Changing static route %s of edge gateway %s", network, edgeGateway.EdgeGateway.Name)
+
+	ctx, cancel := vcdClient.changeContext(d, remove)
+	defer cancel()
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		// The routes are read again as the edge gateway may have changed
+		// while it was busy
+		services, err := readEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF)
+		if err != nil {
+			return govcd.Task{}, err
+		}
+
+		routingService := services.StaticRoutingService
+		if routingService == nil {
+			routingService = &staticRoutingService{IsEnabled: true}
+		}
+		if d.IsNewResource() && findStaticRoute(routingService.StaticRoute, network) != nil {
+			return govcd.Task{}, fmt.Errorf("The edge gateway '%s' already has a static route to %s", edgeGateway.EdgeGateway.Name, network)
+		}
+		if !remove {
+			routingService.IsEnabled = true
+		}
+		routingService.StaticRoute = setStaticRoute(routingService.StaticRoute, network, route)
+
+		return configureEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF, &edgeGatewayServices{
+			StaticRoutingService: routingService,
+		})
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot change static route: network=%s", network)
+	}
+
+	return nil
+}
+
+func expandStaticRoute(d *schema.ResourceData, edgeGateway *types.EdgeGateway) (*staticRoute, error) {
+	interfaceName := d.Get("interface").(string)
+	gatewayInterface := findGatewayInterface(edgeGateway, interfaceName)
+	if gatewayInterface == nil {
+		return nil, fmt.Errorf("The edge gateway '%s' has no interface on the network '%s'", edgeGateway.Name, interfaceName)
+	}
+
+	name := d.Get("description").(string)
+	if name == "" {
+		name = d.Get("network").(string)
+	}
+
+	return &staticRoute{
+		Name:      name,
+		Network:   d.Get("network").(string),
+		NextHopIP: d.Get("next_hop_ip").(string),
+		GatewayInterface: &types.Reference{
+			HREF: gatewayInterface.Network.HREF,
+			Name: gatewayInterface.Network.Name,
+			Type: gatewayInterface.Network.Type,
+		},
+	}, nil
+}
+
+func findStaticRoute(routes []*staticRoute, network string) *staticRoute {
+	for _, route := range routes {
+		if route.Network == network {
+			return route
+		}
+	}
+	return nil
+}
+
+// setStaticRoute replaces the route to network with the given route, the
+// route is added when there is none and removed when the given route is nil
+func setStaticRoute(routes []*staticRoute, network string, route *staticRoute) []*staticRoute {
+	updated := make([]*staticRoute, 0, len(routes)+1)
+	found := false
+	for _, existing := range routes {
+		if existing.Network != network {
+			updated = append(updated, existing)
+			continue
+		}
+		if route != nil && !found {
+			updated = append(updated, route)
+		}
+		found = true
+	}
+
+	if route != nil && !found {
+		updated = append(updated, route)
+	}
+
+	return updated
+}
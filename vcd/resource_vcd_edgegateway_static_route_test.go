@@ -0,0 +1,80 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdEdgeGatewayStaticRouteDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_edgegateway_static_route" {
+			continue
+		}
+
+		_, services, err := testAccReadEdgeGatewayServices(rs)
+		if err != nil {
+			return err
+		}
+
+		if services.StaticRoutingService != nil && findStaticRoute(services.StaticRoutingService.StaticRoute, rs.Primary.ID) != nil {
+			return fmt.Errorf("Static route to %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdEdgeGatewayStaticRoute_Basic(t *testing.T) {
+	edgeGateway := os.Getenv("VCD_EDGE_GATEWAY")
+	externalNetwork := os.Getenv("VCD_EXTERNAL_NETWORK")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckExternalNetwork(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdEdgeGatewayStaticRouteDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayStaticRoute_basic, edgeGateway, "192.168.254.1", externalNetwork),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.test-route", "network", "10.254.0.0/16"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.test-route", "next_hop_ip", "192.168.254.1"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.test-route", "interface", externalNetwork),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.test-route", "description", "terraform-test-route"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_edgegateway_static_route.test-route",
+				ImportState:       true,
+				ImportStateId:     edgeGateway + "/10.254.0.0/16",
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayStaticRoute_basic, edgeGateway, "192.168.254.2", externalNetwork),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.test-route", "network", "10.254.0.0/16"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.test-route", "next_hop_ip", "192.168.254.2"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdEdgeGatewayStaticRoute_basic = `
+resource "vcd_edgegateway_static_route" "test-route" {
+  edge_gateway = "%s"
+  network      = "10.254.0.0/16"
+  next_hop_ip  = "%s"
+  interface    = "%s"
+  description  = "terraform-test-route"
+}
+`
@@ -2,19 +2,35 @@ package vcd
 
 import (
 	"fmt"
-	"github.com/hashicorp/terraform/helper/resource"
+	"log"
+
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
 	"github.com/kublr/govcloudair/types/v56"
-	"log"
+	"github.com/pkg/errors"
 )
 
+// resourceVcdEdgeGatewayVpn manages a single IPsec VPN tunnel of an edge
+// gateway, the tunnel is identified by its name
 func resourceVcdEdgeGatewayVpn() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVcdEdgeGatewayVpnCreate,
 		Read:   resourceVcdEdgeGatewayVpnRead,
+		Update: resourceVcdEdgeGatewayVpnUpdate,
 		Delete: resourceVcdEdgeGatewayVpnDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdEdgeGatewayVpnImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
 
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
@@ -31,55 +47,69 @@ func resourceVcdEdgeGatewayVpn() *schema.Resource {
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
 			},
 
 			"encryption_protocol": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"local_ip_address": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"local_id": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"mtu": &schema.Schema{
 				Type:     schema.TypeInt,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"peer_ip_address": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"peer_id": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+			},
+
+			// The peer is a local peer when not set
+			"third_party_peer_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
 			},
 
 			"shared_secret": &schema.Schema{
+				Type:      schema.TypeString,
+				Required:  true,
+				Sensitive: true,
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"operational": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"error_details": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Computed: true,
 			},
 
 			"local_subnets": &schema.Schema{
 				Type:     schema.TypeSet,
 				Optional: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"local_subnet_name": &schema.Schema{
@@ -103,7 +133,6 @@ func resourceVcdEdgeGatewayVpn() *schema.Resource {
 			"peer_subnets": &schema.Schema{
 				Type:     schema.TypeSet,
 				Optional: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"peer_subnet_name": &schema.Schema{
@@ -128,163 +157,239 @@ func resourceVcdEdgeGatewayVpn() *schema.Resource {
 }
 
 func resourceVcdEdgeGatewayVpnCreate(d *schema.ResourceData, meta interface{}) error {
+	err := changeVpnTunnel(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(d.Get("name").(string))
+
+	return resourceVcdEdgeGatewayVpnRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayVpnUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeVpnTunnel(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdEdgeGatewayVpnRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayVpnDelete(d *schema.ResourceData, meta interface{}) error {
+	return changeVpnTunnel(d, meta, true)
+}
+
+func resourceVcdEdgeGatewayVpnRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	log.Printf("[TRACE] CLIENT: %#v", vcdClient)
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
-	localSubnetsList := d.Get("local_subnets").(*schema.Set).List()
-	peerSubnetsList := d.Get("peer_subnets").(*schema.Set).List()
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error finding edge gateway: %#v", err)
+	}
 
-	localSubnets := make([]*types.IpsecVpnSubnet, len(localSubnetsList))
-	peerSubnets := make([]*types.IpsecVpnSubnet, len(peerSubnetsList))
+	ipsecVpn, err := readIpsecVpnService(vcdClient, &edgeGateway)
+	if err != nil {
+		return err
+	}
 
-	for i, s := range localSubnetsList {
-		ls := s.(map[string]interface{})
-		localSubnets[i] = &types.IpsecVpnSubnet{
-			Name:    ls["local_subnet_name"].(string),
-			Gateway: ls["local_subnet_gateway"].(string),
-			Netmask: ls["local_subnet_mask"].(string),
-		}
+	// The ids of tunnels created by earlier versions of the provider are the
+	// edge gateway name, the tunnel is found by the name in the state
+	var tunnel *ipsecVpnTunnel
+	if ipsecVpn != nil {
+		tunnel = findVpnTunnel(ipsecVpn.Tunnel, d.Get("name").(string))
+	}
+	if tunnel == nil {
+		log.Printf("[DEBUG] VPN tunnel %s no longer exists. Removing from tfstate", d.Get("name").(string))
+		d.SetId("")
+		return nil
 	}
 
-	for i, s := range peerSubnetsList {
-		ls := s.(map[string]interface{})
-		peerSubnets[i] = &types.IpsecVpnSubnet{
-			Name:    ls["peer_subnet_name"].(string),
-			Gateway: ls["peer_subnet_gateway"].(string),
-			Netmask: ls["peer_subnet_mask"].(string),
-		}
+	d.SetId(tunnel.Name)
+	d.Set("description", tunnel.Description)
+	d.Set("encryption_protocol", tunnel.EncryptionProtocol)
+	d.Set("local_ip_address", tunnel.LocalIPAddress)
+	d.Set("local_id", tunnel.LocalID)
+	d.Set("mtu", tunnel.Mtu)
+	d.Set("peer_ip_address", tunnel.PeerIPAddress)
+	d.Set("peer_id", tunnel.PeerID)
+	d.Set("enabled", tunnel.IsEnabled)
+	d.Set("operational", tunnel.IsOperational)
+	d.Set("error_details", tunnel.ErrorDetails)
+	d.Set("local_subnets", flattenVpnSubnets(tunnel.LocalSubnet, "local"))
+	d.Set("peer_subnets", flattenVpnSubnets(tunnel.PeerSubnet, "peer"))
+
+	thirdPartyPeerID := ""
+	if tunnel.IpsecVpnThirdPartyPeer != nil {
+		thirdPartyPeerID = tunnel.IpsecVpnThirdPartyPeer.PeerID
 	}
+	d.Set("third_party_peer_id", thirdPartyPeerID)
 
-	tunnel := &types.GatewayIpsecVpnTunnel{
-		Name:        d.Get("name").(string),
-		Description: d.Get("description").(string),
-		IpsecVpnLocalPeer: &types.IpsecVpnLocalPeer{
-			ID:   "",
-			Name: "",
-		},
-		EncryptionProtocol: d.Get("encryption_protocol").(string),
-		LocalIPAddress:     d.Get("local_ip_address").(string),
-		LocalID:            d.Get("local_id").(string),
-		LocalSubnet:        localSubnets,
-		Mtu:                d.Get("mtu").(int),
-		PeerID:             d.Get("peer_id").(string),
-		PeerIPAddress:      d.Get("peer_ip_address").(string),
-		PeerSubnet:         peerSubnets,
-		SharedSecret:       d.Get("shared_secret").(string),
-		IsEnabled:          true,
+	// An encrypted shared secret never matches the configured one
+	if !tunnel.SharedSecretEncrypted {
+		d.Set("shared_secret", tunnel.SharedSecret)
 	}
 
-	tunnels := make([]*types.GatewayIpsecVpnTunnel, 1)
-	tunnels[0] = tunnel
+	return nil
+}
 
-	ipsecVPNConfig := &types.EdgeGatewayServiceConfiguration{
-		Xmlns: "http://www.vmware.com/vcloud/v1.5",
-		GatewayIpsecVpnService: &types.GatewayIpsecVpnService{
-			IsEnabled: true,
-			Tunnel:    tunnels,
-		},
+// resourceVcdEdgeGatewayVpnImport accepts "edge-gateway/tunnel-name", or the
+// edge gateway name when the edge gateway has a single tunnel
+func resourceVcdEdgeGatewayVpnImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("[INFO] ipsecVPNConfig: %#v", ipsecVPNConfig)
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<tunnel-name>")
+	if err != nil {
+		edgeGateway, findErr := vdc.FindEdgeGateway(d.Id())
+		if findErr != nil {
+			return nil, err
+		}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		edgeGateway.Refresh()
-		task, err := edgeGateway.AddIpsecVPN(ipsecVPNConfig)
-		if err != nil {
-			log.Printf("[INFO] Error setting ipsecVPNConfig rules: %s", err)
-			return resource.RetryableError(
-				fmt.Errorf("Error setting ipsecVPNConfig rules: %#v", err))
+		ipsecVpn, readErr := readIpsecVpnService(vcdClient, &edgeGateway)
+		if readErr != nil {
+			return nil, readErr
+		}
+		if ipsecVpn == nil || len(ipsecVpn.Tunnel) != 1 {
+			return nil, err
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+		parts = []string{d.Id(), ipsecVpn.Tunnel[0].Name}
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.Set("name", parts[1])
+	d.SetId(parts[1])
+
+	err = resourceVcdEdgeGatewayVpnRead(d, meta)
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return nil, err
 	}
 
-	d.SetId(d.Get("edge_gateway").(string))
+	if d.Id() == "" {
+		return nil, fmt.Errorf("VPN tunnel %s not found on edge gateway %s", parts[1], parts[0])
+	}
 
-	return resourceVcdEdgeGatewayVpnRead(d, meta)
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceVcdEdgeGatewayVpnDelete(d *schema.ResourceData, meta interface{}) error {
+// changeVpnTunnel adds, updates or removes the tunnel of the resource, the
+// other tunnels of the edge gateway are sent back as they are. The service is
+// enabled when a tunnel is added and disabled once it has no tunnels left. A
+// tunnel created outside of Terraform is not taken over.
+func changeVpnTunnel(d *schema.ResourceData, meta interface{}, remove bool) error {
 	vcdClient := meta.(*VCDClient)
 
-	log.Printf("[TRACE] CLIENT: %#v", vcdClient)
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
 	vcdClient.Mutex.Lock()
 	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	name := d.Get("name").(string)
 
-	ipsecVPNConfig := &types.EdgeGatewayServiceConfiguration{
-		Xmlns: "http://www.vmware.com/vcloud/v1.5",
-		GatewayIpsecVpnService: &types.GatewayIpsecVpnService{
-			IsEnabled: false,
-		},
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	log.Printf("[INFO] ipsecVPNConfig: %#v", ipsecVPNConfig)
-
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		edgeGateway.Refresh()
-		task, err := edgeGateway.AddIpsecVPN(ipsecVPNConfig)
+	var tunnel *ipsecVpnTunnel
+	if remove {
+		ipsecVpn, err := readIpsecVpnService(vcdClient, &edgeGateway)
 		if err != nil {
-			log.Printf("[INFO] Error setting ipsecVPNConfig rules: %s", err)
-			return resource.RetryableError(
-				fmt.Errorf("Error setting ipsecVPNConfig rules: %#v", err))
+			return err
 		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
-	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		if ipsecVpn == nil || findVpnTunnel(ipsecVpn.Tunnel, name) == nil {
+			log.Printf("[DEBUG] VPN tunnel %s is already removed", name)
+			return nil
+		}
+	} else {
+		tunnel = expandVpnTunnel(d)
 	}
 
-	d.SetId(d.Get("edge_gateway").(string))
+	log.Printf("[INFO] Changing VPN tunnel %s of edge gateway %s", name, edgeGateway.EdgeGateway.Name)
+
+	ctx, cancel := vcdClient.changeContext(d, remove)
+	defer cancel()
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		// The tunnels are read again as the edge gateway may have changed
+		// while it was busy
+		ipsecVpn, err := readIpsecVpnService(vcdClient, &edgeGateway)
+		if err != nil {
+			return govcd.Task{}, err
+		}
+		if ipsecVpn == nil {
+			ipsecVpn = &ipsecVpnService{}
+		}
+		if d.IsNewResource() && findVpnTunnel(ipsecVpn.Tunnel, name) != nil {
+			return govcd.Task{}, fmt.Errorf("The edge gateway '%s' already has a VPN tunnel '%s'", edgeGateway.EdgeGateway.Name, name)
+		}
+
+		ipsecVpn.Tunnel = setVpnTunnel(ipsecVpn.Tunnel, name, tunnel)
+		ipsecVpn.IsEnabled = len(ipsecVpn.Tunnel) > 0 && (ipsecVpn.IsEnabled || !remove)
 
+		return configureEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF, &edgeGatewayServices{
+			IpsecVpnService: ipsecVpn,
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("Error finding edge gateway: %#v", err)
+		return errors.Wrapf(err, "cannot change VPN tunnel: %s", name)
 	}
 
 	return nil
 }
 
-func resourceVcdEdgeGatewayVpnRead(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+// readIpsecVpnService reads the IPsec VPN service of the edge gateway, it is
+// nil when the edge gateway has none
+func readIpsecVpnService(vcdClient *VCDClient, edgeGateway *govcd.EdgeGateway) (*ipsecVpnService, error) {
+	services, err := readEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF)
 	if err != nil {
-		return fmt.Errorf("Error finding edge gateway: %#v", err)
+		return nil, err
 	}
+	return services.IpsecVpnService, nil
+}
 
-	egsc := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.GatewayIpsecVpnService
-
-	if len(egsc.Tunnel) == 0 {
-		d.SetId("")
-		return nil
+func expandVpnTunnel(d *schema.ResourceData) *ipsecVpnTunnel {
+	tunnel := &ipsecVpnTunnel{
+		Name:               d.Get("name").(string),
+		Description:        d.Get("description").(string),
+		EncryptionProtocol: d.Get("encryption_protocol").(string),
+		LocalIPAddress:     d.Get("local_ip_address").(string),
+		LocalID:            d.Get("local_id").(string),
+		LocalSubnet:        expandVpnSubnets(d.Get("local_subnets").(*schema.Set).List(), "local"),
+		Mtu:                d.Get("mtu").(int),
+		PeerID:             d.Get("peer_id").(string),
+		PeerIPAddress:      d.Get("peer_ip_address").(string),
+		PeerSubnet:         expandVpnSubnets(d.Get("peer_subnets").(*schema.Set).List(), "peer"),
+		SharedSecret:       d.Get("shared_secret").(string),
+		IsEnabled:          d.Get("enabled").(bool),
 	}
 
-	if len(egsc.Tunnel) == 1 {
-		tunnel := egsc.Tunnel[0]
-		d.Set("name", tunnel.Name)
-		d.Set("description", tunnel.Description)
-		d.Set("encryption_protocol", tunnel.EncryptionProtocol)
-		d.Set("local_ip_address", tunnel.LocalIPAddress)
-		d.Set("local_id", tunnel.LocalID)
-		d.Set("mtu", tunnel.Mtu)
-		d.Set("peer_ip_address", tunnel.PeerIPAddress)
-		d.Set("peer_id", tunnel.PeerID)
-		d.Set("shared_secret", tunnel.SharedSecret)
-		d.Set("local_subnets", tunnel.LocalSubnet)
-		d.Set("peer_subnets", tunnel.PeerSubnet)
+	if thirdPartyPeerID := d.Get("third_party_peer_id").(string); thirdPartyPeerID != "" {
+		tunnel.IpsecVpnThirdPartyPeer = &types.IpsecVpnThirdPartyPeer{PeerID: thirdPartyPeerID}
 	} else {
-		return fmt.Errorf("Multiple tunnels not currently supported")
+		tunnel.IpsecVpnLocalPeer = &types.IpsecVpnLocalPeer{}
 	}
 
-	return nil
+	return tunnel
 }
@@ -0,0 +1,247 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// resourceVcdFirewallRule manages a single firewall rule of an edge gateway,
+// the rule is identified by the id vCD assigns to it
+func resourceVcdFirewallRule() *schema.Resource {
+	ruleSchema := firewallRuleSchema()
+	ruleSchema["org"] = orgSchema(true)
+	ruleSchema["vdc"] = vdcSchema(true)
+	ruleSchema["edge_gateway"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+		ForceNew: true,
+	}
+	ruleSchema["protocol"].ValidateFunc = validation.StringInSlice([]string{"tcp", "udp", "icmp", "any"}, false)
+	// The rules are placed relative to another rule when they are created
+	// or when the reference changes
+	ruleSchema["before"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		ConflictsWith: []string{"after"},
+	}
+	ruleSchema["after"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		ConflictsWith: []string{"before"},
+	}
+	// The ports and the IPs are read back in lower case
+	for _, key := range []string{"destination_port", "destination_ip", "source_port", "source_ip"} {
+		ruleSchema[key].DiffSuppressFunc = suppressCaseDifferences
+	}
+
+	return &schema.Resource{
+		Create: resourceVcdFirewallRuleCreate,
+		Read:   resourceVcdFirewallRuleRead,
+		Update: resourceVcdFirewallRuleUpdate,
+		Delete: resourceVcdFirewallRuleDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdFirewallRuleImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
+
+		Schema: ruleSchema,
+	}
+}
+
+func resourceVcdFirewallRuleCreate(d *schema.ResourceData, meta interface{}) error {
+	err := changeFirewallRule(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdFirewallRuleRead(d, meta)
+}
+
+func resourceVcdFirewallRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeFirewallRule(d, meta, false)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdFirewallRuleRead(d, meta)
+}
+
+func resourceVcdFirewallRuleDelete(d *schema.ResourceData, meta interface{}) error {
+	return changeFirewallRule(d, meta, true)
+}
+
+func resourceVcdFirewallRuleRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	var rule *types.FirewallRule
+	if firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService; firewallService != nil {
+		rule = findFirewallRule(firewallService.FirewallRule, d.Id())
+	}
+	if rule == nil {
+		log.Printf("[DEBUG] Firewall rule %s no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	for key, value := range flattenFirewallRule(rule) {
+		if key != "id" {
+			d.Set(key, value)
+		}
+	}
+
+	return nil
+}
+
+// resourceVcdFirewallRuleImport accepts "edge-gateway/rule-id"
+func resourceVcdFirewallRuleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<rule-id>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
+
+	err = resourceVcdFirewallRuleRead(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("firewall rule %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// changeFirewallRule adds, updates or removes the rule of the resource, the
+// other rules of the edge gateway are sent back as they are. The id of a new
+// rule is set once vCD has assigned it.
+func changeFirewallRule(d *schema.ResourceData, meta interface{}, remove bool) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	rule := expandFirewallRule(map[string]interface{}{
+		"description":        d.Get("description"),
+		"policy":             d.Get("policy"),
+		"protocol":           d.Get("protocol"),
+		"destination_port":   d.Get("destination_port"),
+		"destination_ip":     d.Get("destination_ip"),
+		"source_port":        d.Get("source_port"),
+		"source_ip":          d.Get("source_ip"),
+		"is_enabled":         d.Get("is_enabled"),
+		"enable_logging":     d.Get("enable_logging"),
+		"match_on_translate": d.Get("match_on_translate"),
+	})
+	rule.ID = d.Id()
+
+	// An existing rule is only moved when its reference changes
+	before, after := "", ""
+	if d.IsNewResource() || d.HasChange("before") || d.HasChange("after") {
+		before, after = d.Get("before").(string), d.Get("after").(string)
+	}
+
+	if remove {
+		firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
+		if firewallService == nil || findFirewallRule(firewallService.FirewallRule, d.Id()) == nil {
+			log.Printf("[DEBUG] Firewall rule %s is already removed", d.Id())
+			return nil
+		}
+	}
+
+	log.Printf("[INFO] Changing firewall rule '%s' of edge gateway %s", rule.Description, edgeGateway.EdgeGateway.Name)
+
+	position := 0
+	ctx, cancel := vcdClient.changeContext(d, remove)
+	defer cancel()
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		// The rules are read again as the edge gateway may have changed
+		// while it was busy
+		err := edgeGateway.Refresh()
+		if err != nil {
+			return govcd.Task{}, errors.Wrapf(err, "cannot read edge gateway: %s", edgeGateway.EdgeGateway.Name)
+		}
+
+		firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
+		// The default action of the firewall is left to vcd_firewall_rules
+		if firewallService == nil {
+			return govcd.Task{}, fmt.Errorf("The edge gateway '%s' has no firewall, configure it with vcd_firewall_rules first", edgeGateway.EdgeGateway.Name)
+		}
+
+		if remove {
+			firewallService.FirewallRule, _ = replaceFirewallRules(firewallService.FirewallRule, []string{d.Id()}, nil)
+		} else {
+			firewallService.FirewallRule, position, err = placeFirewallRule(firewallService.FirewallRule, rule, before, after)
+			if err != nil {
+				return govcd.Task{}, err
+			}
+		}
+
+		return configureEdgeGatewayServices(&vcdClient.Client, edgeGateway.EdgeGateway.HREF, &edgeGatewayServices{
+			FirewallService: firewallService,
+		})
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot change firewall rule: %s", rule.Description)
+	}
+
+	if remove {
+		return nil
+	}
+
+	// vCD assigns the id of a new rule, it is found by its position
+	err = edgeGateway.Refresh()
+	if err != nil {
+		return errors.Wrapf(err, "cannot read edge gateway: %s", edgeGateway.EdgeGateway.Name)
+	}
+
+	firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
+	if firewallService == nil || position >= len(firewallService.FirewallRule) {
+		return fmt.Errorf("Unable to find firewall rule '%s' on edge gateway %s", rule.Description, edgeGateway.EdgeGateway.Name)
+	}
+	d.SetId(firewallService.FirewallRule[position].ID)
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdFirewallRuleDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_firewall_rule" {
+			continue
+		}
+
+		_, services, err := testAccReadEdgeGatewayServices(rs)
+		if err != nil {
+			return err
+		}
+
+		if services.FirewallService != nil && findFirewallRule(services.FirewallService.FirewallRule, rs.Primary.ID) != nil {
+			return fmt.Errorf("Firewall rule %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdFirewallRule_Basic(t *testing.T) {
+	edgeGateway := os.Getenv("VCD_EDGE_GATEWAY")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdFirewallRuleDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdFirewallRule_basic, edgeGateway, "any"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(
+						"vcd_firewall_rule.test-allow", "id"),
+					resource.TestCheckResourceAttr(
+						"vcd_firewall_rule.test-allow", "policy", "allow"),
+					resource.TestCheckResourceAttr(
+						"vcd_firewall_rule.test-allow", "source_ip", "any"),
+					resource.TestCheckResourceAttr(
+						"vcd_firewall_rule.test-deny", "policy", "drop"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_firewall_rule.test-allow",
+				ImportState:       true,
+				ImportStateIdFunc: testAccVcdFirewallRuleImportID(edgeGateway, "vcd_firewall_rule.test-allow"),
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdFirewallRule_basic, edgeGateway, "10.254.0.0/24"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_firewall_rule.test-allow", "source_ip", "10.254.0.0/24"),
+				),
+			},
+		},
+	})
+}
+
+// testAccVcdFirewallRuleImportID returns the import ID of the rule, its id
+// is assigned by vCD
+func testAccVcdFirewallRuleImportID(edgeGateway, name string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("Not found: %s", name)
+		}
+		return edgeGateway + "/" + rs.Primary.ID, nil
+	}
+}
+
+const testAccCheckVcdFirewallRule_basic = `
+resource "vcd_firewall_rule" "test-allow" {
+  edge_gateway     = "%[1]s"
+  description      = "terraform-test-allow"
+  policy           = "allow"
+  protocol         = "tcp"
+  destination_port = "22"
+  destination_ip   = "10.254.0.10"
+  source_port      = "any"
+  source_ip        = "%[2]s"
+}
+
+resource "vcd_firewall_rule" "test-deny" {
+  edge_gateway     = "%[1]s"
+  description      = "terraform-test-deny"
+  policy           = "drop"
+  protocol         = "tcp"
+  destination_port = "22"
+  destination_ip   = "10.254.0.10"
+  source_port      = "any"
+  source_ip        = "any"
+  after            = "${vcd_firewall_rule.test-allow.id}"
+}
+`
@@ -8,15 +8,36 @@ import (
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
 )
 
 func resourceVcdFirewallRules() *schema.Resource {
+	ruleSchema := firewallRuleSchema()
+	ruleSchema["id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		Computed: true,
+	}
+
 	return &schema.Resource{
 		Create: resourceVcdFirewallRulesCreate,
+		Update: resourceVcdFirewallRulesUpdate,
 		Delete: resourceFirewallRulesDelete,
 		Read:   resourceFirewallRulesRead,
+		Importer: &schema.ResourceImporter{
+			State: resourceFirewallRulesImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
@@ -26,56 +47,13 @@ func resourceVcdFirewallRules() *schema.Resource {
 			"default_action": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"rule": &schema.Schema{
 				Type:     schema.TypeList,
 				Optional: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"id": &schema.Schema{
-							Type:     schema.TypeString,
-							Optional: true,
-							Computed: true,
-						},
-
-						"description": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"policy": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"protocol": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"destination_port": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"destination_ip": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"source_port": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"source_ip": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
-						},
-					},
+					Schema: ruleSchema,
 				},
 			},
 		},
@@ -83,53 +61,69 @@ func resourceVcdFirewallRules() *schema.Resource {
 }
 
 func resourceVcdFirewallRulesCreate(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
-
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	err := configureFirewallRules(d, meta, nil)
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %s", err)
+		return err
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		edgeGateway.Refresh()
-		firewallRules, _ := expandFirewallRules(d, edgeGateway.EdgeGateway)
-		task, err := edgeGateway.CreateFirewallRules(d.Get("default_action").(string), firewallRules)
-		if err != nil {
-			log.Printf("[INFO] Error setting firewall rules: %s", err)
-			return resource.RetryableError(
-				fmt.Errorf("Error setting firewall rules: %#v", err))
-		}
+	d.SetId(d.Get("edge_gateway").(string))
 
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+	return resourceFirewallRulesRead(d, meta)
+}
+
+func resourceVcdFirewallRulesUpdate(d *schema.ResourceData, meta interface{}) error {
+	// The rules of the previous configuration are replaced
+	oldRules, _ := d.GetChange("rule")
+
+	err := configureFirewallRules(d, meta, firewallRuleIDs(oldRules.([]interface{})))
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return err
 	}
 
-	d.SetId(d.Get("edge_gateway").(string))
-
 	return resourceFirewallRulesRead(d, meta)
 }
 
 func resourceFirewallRulesDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	vcdClient.Mutex.Lock()
 	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
-
-	firewallRules := deleteFirewallRules(d, edgeGateway.EdgeGateway)
-	defaultAction := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.DefaultAction
-	task, err := edgeGateway.CreateFirewallRules(defaultAction, firewallRules)
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
 	if err != nil {
-		return fmt.Errorf("Error deleting firewall rules: %#v", err)
+		return fmt.Errorf("Unable to find edge gateway: %s", err)
 	}
 
-	err = task.WaitTaskCompletion()
+	owned := firewallRuleIDs(d.Get("rule").([]interface{}))
+
+	err = retryCall(ctx, func() *resource.RetryError {
+		err := edgeGateway.Refresh()
+		if err != nil {
+			return retryError(errors.Wrap(err, "Error refreshing edge gateway"))
+		}
+
+		firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
+		if firewallService == nil {
+			return nil
+		}
+
+		firewallRules, _ := replaceFirewallRules(firewallService.FirewallRule, owned, nil)
+		task, err := edgeGateway.CreateFirewallRules(firewallService.DefaultAction, firewallRules)
+		if err != nil {
+			return retryError(errors.Wrap(err, "Error deleting firewall rules"))
+		}
+
+		return waitTask(ctx, task)
+	})
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return fmt.Errorf("Error completing tasks: %s", err)
 	}
 
 	return nil
@@ -138,61 +132,164 @@ func resourceFirewallRulesDelete(d *schema.ResourceData, meta interface{}) error
 func resourceFirewallRulesRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("Error finding edge gateway: %#v", err)
 	}
+
+	firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
+	if firewallService == nil {
+		log.Printf("[DEBUG] Edge gateway %s has no firewall service", edgeGateway.EdgeGateway.Name)
+		d.Set("rule", []interface{}{})
+		return nil
+	}
+
+	// Rules without an id were created by an earlier version of the
+	// provider, they are searched by their content
+	owned := make([]string, 0)
 	ruleList := d.Get("rule").([]interface{})
-	firewallRules := *edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
-	rulesCount := d.Get("rule.#").(int)
-	for i := 0; i < rulesCount; i++ {
-		prefix := fmt.Sprintf("rule.%d", i)
-		if d.Get(prefix+".id").(string) == "" {
+	for i, rule := range ruleList {
+		id := rule.(map[string]interface{})["id"].(string)
+		if id == "" {
 			log.Printf("[INFO] Rule %d has no id. Searching...", i)
-			ruleid, err := matchFirewallRule(d, prefix, firewallRules.FirewallRule)
-			if err == nil {
-				currentRule := ruleList[i].(map[string]interface{})
-				currentRule["id"] = ruleid
-				ruleList[i] = currentRule
-			}
+			id, _ = matchFirewallRule(d, fmt.Sprintf("rule.%d", i), unownedFirewallRules(firewallService.FirewallRule, owned))
+		}
+		if id != "" {
+			owned = append(owned, id)
 		}
 	}
-	d.Set("rule", ruleList)
-	d.Set("default_action", firewallRules.DefaultAction)
+
+	// The rules deleted out of band are left out and the rules changed out
+	// of band are reported with their current content, in the order of the
+	// edge gateway
+	d.Set("rule", flattenOwnedFirewallRules(firewallService.FirewallRule, owned))
+	d.Set("default_action", firewallService.DefaultAction)
 
 	return nil
 }
 
-func deleteFirewallRules(d *schema.ResourceData, gateway *types.EdgeGateway) []*types.FirewallRule {
-	firewallRules := gateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.FirewallRule
-	rulesCount := d.Get("rule.#").(int)
-	fwrules := make([]*types.FirewallRule, 0, len(firewallRules)-rulesCount)
-
-	for _, f := range firewallRules {
-		keep := true
-		for i := 0; i < rulesCount; i++ {
-			if d.Get(fmt.Sprintf("rule.%d.id", i)).(string) != f.ID {
-				continue
-			}
-			keep = false
+// resourceFirewallRulesImport accepts the edge gateway name, all the rules
+// currently defined on the gateway are adopted
+func resourceFirewallRulesImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("Error finding edge gateway: %#v", err)
+	}
+
+	firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
+	if firewallService == nil {
+		return nil, fmt.Errorf("Edge gateway %s has no firewall service", d.Id())
+	}
+
+	ruleList := make([]interface{}, 0, len(firewallService.FirewallRule))
+	for _, rule := range firewallService.FirewallRule {
+		ruleList = append(ruleList, flattenFirewallRule(rule))
+	}
+
+	d.Set("edge_gateway", d.Id())
+	d.Set("rule", ruleList)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// configureFirewallRules replaces the owned rules of the edge gateway with the
+// configured ones. The configured rules take the place of the first owned
+// rule, they are added after the other rules when none is left.
+func configureFirewallRules(d *schema.ResourceData, meta interface{}, owned []string) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %s", err)
+	}
+
+	firewallRules := expandFirewallRules(d)
+	position := 0
+
+	ctx, cancel := vcdClient.changeContext(d, false)
+	defer cancel()
+
+	err = retryCall(ctx, func() *resource.RetryError {
+		err := edgeGateway.Refresh()
+		if err != nil {
+			return retryError(errors.Wrap(err, "Error refreshing edge gateway"))
 		}
-		if keep {
-			fwrules = append(fwrules, f)
+
+		var current []*types.FirewallRule
+		if firewallService := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService; firewallService != nil {
+			current = firewallService.FirewallRule
 		}
+
+		var updated []*types.FirewallRule
+		updated, position = replaceFirewallRules(current, owned, firewallRules)
+		task, err := edgeGateway.CreateFirewallRules(d.Get("default_action").(string), updated)
+		if err != nil {
+			log.Printf("[INFO] Error setting firewall rules: %s", err)
+			return retryError(errors.Wrap(err, "Error setting firewall rules"))
+		}
+
+		return waitTask(ctx, task)
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %s", err)
+	}
+
+	// The new rules get their id from vCD, they are found by their position
+	err = edgeGateway.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing edge gateway: %#v", err)
+	}
+
+	current := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.FirewallRule
+	ruleList := d.Get("rule").([]interface{})
+	for i := range ruleList {
+		if position+i >= len(current) {
+			break
+		}
+		rule := ruleList[i].(map[string]interface{})
+		rule["id"] = current[position+i].ID
+		ruleList[i] = rule
 	}
-	return fwrules
+	d.Set("rule", ruleList)
+
+	return nil
 }
 
 func matchFirewallRule(d *schema.ResourceData, prefix string, rules []*types.FirewallRule) (string, error) {
-
 	for _, m := range rules {
-		if d.Get(prefix+".description").(string) == m.Description &&
-			d.Get(prefix+".policy").(string) == m.Policy &&
-			strings.ToLower(d.Get(prefix+".protocol").(string)) == getProtocol(*m.Protocols) &&
-			strings.ToLower(d.Get(prefix+".destination_port").(string)) == getPortString(m.Port) &&
-			strings.ToLower(d.Get(prefix+".destination_ip").(string)) == strings.ToLower(m.DestinationIP) &&
-			strings.ToLower(d.Get(prefix+".source_port").(string)) == getPortString(m.SourcePort) &&
-			strings.ToLower(d.Get(prefix+".source_ip").(string)) == strings.ToLower(m.SourceIP) {
+		rule := flattenFirewallRule(m)
+		if d.Get(prefix+".description").(string) == rule["description"] &&
+			d.Get(prefix+".policy").(string) == rule["policy"] &&
+			strings.ToLower(d.Get(prefix+".protocol").(string)) == rule["protocol"] &&
+			strings.ToLower(d.Get(prefix+".destination_port").(string)) == rule["destination_port"] &&
+			strings.ToLower(d.Get(prefix+".destination_ip").(string)) == rule["destination_ip"] &&
+			strings.ToLower(d.Get(prefix+".source_port").(string)) == rule["source_port"] &&
+			strings.ToLower(d.Get(prefix+".source_ip").(string)) == rule["source_ip"] {
 			return m.ID, nil
 		}
 	}
@@ -0,0 +1,241 @@
+package vcd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func resourceVcdLBPool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdLBPoolCreate,
+		Read:   resourceVcdLBPoolRead,
+		Update: resourceVcdLBPoolUpdate,
+		Delete: resourceVcdLBPoolDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdLBPoolImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+			"edge_gateway": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			// The pools of an edge gateway are identified by their name
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"service_port": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem:     lbPoolServicePortSchema(),
+			},
+			"member": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip_address": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: ValidateIPv4(),
+						},
+						"weight": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntBetween(0, 100),
+						},
+					},
+				},
+				Set: resourceVcdLBPoolMemberHash,
+			},
+			"operational": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVcdLBPoolCreate(d *schema.ResourceData, meta interface{}) error {
+	name := d.Get("name").(string)
+
+	err := changeLoadBalancerService(d, meta, false, func(edgeGateway *types.EdgeGateway, service *loadBalancerService) error {
+		if findLBPool(service, name) != nil {
+			return fmt.Errorf("The edge gateway '%s' already has a load balancer pool '%s'", edgeGateway.Name, name)
+		}
+
+		pool := &lbPool{Name: name}
+		err := expandLBPool(d, pool)
+		if err != nil {
+			return err
+		}
+
+		service.Pool = append(service.Pool, pool)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(name)
+
+	return resourceVcdLBPoolRead(d, meta)
+}
+
+func resourceVcdLBPoolUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeLoadBalancerService(d, meta, false, func(edgeGateway *types.EdgeGateway, service *loadBalancerService) error {
+		pool := findLBPool(service, d.Id())
+		if pool == nil {
+			return fmt.Errorf("The edge gateway '%s' has no load balancer pool '%s'", edgeGateway.Name, d.Id())
+		}
+
+		return expandLBPool(d, pool)
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdLBPoolRead(d, meta)
+}
+
+func resourceVcdLBPoolDelete(d *schema.ResourceData, meta interface{}) error {
+	_, service, err := readLoadBalancerService(d, meta)
+	if err != nil {
+		return err
+	}
+	if findLBPool(service, d.Id()) == nil {
+		log.Printf("[DEBUG] Load balancer pool %s is already removed", d.Id())
+		return nil
+	}
+
+	return changeLoadBalancerService(d, meta, true, func(edgeGateway *types.EdgeGateway, service *loadBalancerService) error {
+		pools := make([]*lbPool, 0, len(service.Pool))
+		for _, pool := range service.Pool {
+			if pool.Name != d.Id() {
+				pools = append(pools, pool)
+			}
+		}
+		service.Pool = pools
+		return nil
+	})
+}
+
+func resourceVcdLBPoolRead(d *schema.ResourceData, meta interface{}) error {
+	_, service, err := readLoadBalancerService(d, meta)
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pool := findLBPool(service, d.Id())
+	if pool == nil {
+		log.Printf("[DEBUG] Load balancer pool %s no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(pool.Member))
+	for _, member := range pool.Member {
+		members = append(members, map[string]interface{}{
+			"ip_address": member.IPAddress,
+			"weight":     lbNumber(member.Weight),
+		})
+	}
+
+	d.Set("name", pool.Name)
+	d.Set("description", pool.Description)
+	d.Set("service_port", flattenLBPoolServicePorts(pool.ServicePort))
+	d.Set("member", members)
+	d.Set("operational", pool.Operational)
+
+	return nil
+}
+
+// resourceVcdLBPoolImport accepts "edge-gateway/pool-name"
+func resourceVcdLBPoolImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<pool-name>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
+
+	err = resourceVcdLBPoolRead(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("load balancer pool %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// expandLBPool sets the configuration of the resource on the pool, the ID
+// of an existing pool and the service ports of its remaining members are kept
+func expandLBPool(d *schema.ResourceData, pool *lbPool) error {
+	servicePorts, err := expandLBPoolServicePorts(d.Get("service_port").([]interface{}))
+	if err != nil {
+		return err
+	}
+
+	pool.Description = d.Get("description").(string)
+	pool.ServicePort = mergeLBPoolServicePorts(pool.ServicePort, servicePorts)
+
+	existing := make(map[string]*lbPoolMember, len(pool.Member))
+	for _, member := range pool.Member {
+		existing[member.IPAddress] = member
+	}
+
+	pool.Member = make([]*lbPoolMember, 0)
+	for _, item := range d.Get("member").(*schema.Set).List() {
+		member := item.(map[string]interface{})
+		poolMember := &lbPoolMember{
+			IPAddress: member["ip_address"].(string),
+			Weight:    strconv.Itoa(member["weight"].(int)),
+		}
+		if current, ok := existing[poolMember.IPAddress]; ok {
+			poolMember.ServicePort = current.ServicePort
+		}
+		pool.Member = append(pool.Member, poolMember)
+	}
+
+	return nil
+}
+
+func resourceVcdLBPoolMemberHash(v interface{}) int {
+	var buf bytes.Buffer
+	m := v.(map[string]interface{})
+	buf.WriteString(fmt.Sprintf("%s-%d-", m["ip_address"].(string), m["weight"].(int)))
+	return hashcode.String(buf.String())
+}
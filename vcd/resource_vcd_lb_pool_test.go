@@ -0,0 +1,121 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdLBPoolDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_lb_pool" {
+			continue
+		}
+
+		_, services, err := testAccReadEdgeGatewayServices(rs)
+		if err != nil {
+			return err
+		}
+
+		if services.LoadBalancerService != nil && findLBPool(services.LoadBalancerService, rs.Primary.ID) != nil {
+			return fmt.Errorf("Load balancer pool %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdLBPool_Basic(t *testing.T) {
+	edgeGateway := os.Getenv("VCD_EDGE_GATEWAY")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLBPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLBPool_basic0, edgeGateway),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "name", "terraform-test-pool"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "service_port.#", "1"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "service_port.0.algorithm", "ROUND_ROBIN"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "service_port.0.port", "6443"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "member.#", "2"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_lb_pool.test-pool",
+				ImportState:       true,
+				ImportStateId:     edgeGateway + "/terraform-test-pool",
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLBPool_basic1, edgeGateway),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "description", "updated"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "service_port.0.algorithm", "LEAST_CONN"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_pool.test-pool", "member.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdLBPool_basic0 = `
+resource "vcd_lb_pool" "test-pool" {
+  edge_gateway = "%s"
+  name         = "terraform-test-pool"
+
+  service_port {
+    protocol  = "TCP"
+    algorithm = "ROUND_ROBIN"
+    port      = 6443
+
+    health_check {
+      mode = "TCP"
+    }
+  }
+
+  member {
+    ip_address = "10.254.0.11"
+  }
+
+  member {
+    ip_address = "10.254.0.12"
+  }
+}
+`
+
+const testAccCheckVcdLBPool_basic1 = `
+resource "vcd_lb_pool" "test-pool" {
+  edge_gateway = "%s"
+  name         = "terraform-test-pool"
+  description  = "updated"
+
+  service_port {
+    protocol  = "TCP"
+    algorithm = "LEAST_CONN"
+    port      = 6443
+
+    health_check {
+      mode = "TCP"
+    }
+  }
+
+  member {
+    ip_address = "10.254.0.11"
+    weight     = 2
+  }
+}
+`
@@ -0,0 +1,233 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
+)
+
+func resourceVcdLBVirtualServer() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdLBVirtualServerCreate,
+		Read:   resourceVcdLBVirtualServerRead,
+		Update: resourceVcdLBVirtualServerUpdate,
+		Delete: resourceVcdLBVirtualServerDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdLBVirtualServerImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+			"edge_gateway": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			// The virtual servers of an edge gateway are identified by their name
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"interface": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"ip_address": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: ValidateIPv4(),
+			},
+			"pool": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"logging": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"service_profile": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem:     lbVirtualServerServiceProfileSchema(),
+			},
+		},
+	}
+}
+
+func resourceVcdLBVirtualServerCreate(d *schema.ResourceData, meta interface{}) error {
+	name := d.Get("name").(string)
+
+	err := changeLoadBalancerService(d, meta, false, func(edgeGateway *types.EdgeGateway, service *loadBalancerService) error {
+		if findLBVirtualServer(service, name) != nil {
+			return fmt.Errorf("The edge gateway '%s' already has a load balancer virtual server '%s'", edgeGateway.Name, name)
+		}
+
+		virtualServer := &lbVirtualServer{Name: name}
+		err := expandLBVirtualServer(d, edgeGateway, service, virtualServer)
+		if err != nil {
+			return err
+		}
+
+		service.VirtualServer = append(service.VirtualServer, virtualServer)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(name)
+
+	return resourceVcdLBVirtualServerRead(d, meta)
+}
+
+func resourceVcdLBVirtualServerUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeLoadBalancerService(d, meta, false, func(edgeGateway *types.EdgeGateway, service *loadBalancerService) error {
+		virtualServer := findLBVirtualServer(service, d.Id())
+		if virtualServer == nil {
+			return fmt.Errorf("The edge gateway '%s' has no load balancer virtual server '%s'", edgeGateway.Name, d.Id())
+		}
+
+		return expandLBVirtualServer(d, edgeGateway, service, virtualServer)
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdLBVirtualServerRead(d, meta)
+}
+
+func resourceVcdLBVirtualServerDelete(d *schema.ResourceData, meta interface{}) error {
+	_, service, err := readLoadBalancerService(d, meta)
+	if err != nil {
+		return err
+	}
+	if findLBVirtualServer(service, d.Id()) == nil {
+		log.Printf("[DEBUG] Load balancer virtual server %s is already removed", d.Id())
+		return nil
+	}
+
+	return changeLoadBalancerService(d, meta, true, func(edgeGateway *types.EdgeGateway, service *loadBalancerService) error {
+		virtualServers := make([]*lbVirtualServer, 0, len(service.VirtualServer))
+		for _, virtualServer := range service.VirtualServer {
+			if virtualServer.Name != d.Id() {
+				virtualServers = append(virtualServers, virtualServer)
+			}
+		}
+		service.VirtualServer = virtualServers
+		return nil
+	})
+}
+
+func resourceVcdLBVirtualServerRead(d *schema.ResourceData, meta interface{}) error {
+	edgeGateway, service, err := readLoadBalancerService(d, meta)
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	virtualServer := findLBVirtualServer(service, d.Id())
+	if virtualServer == nil {
+		log.Printf("[DEBUG] Load balancer virtual server %s no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", virtualServer.Name)
+	d.Set("description", virtualServer.Description)
+	d.Set("enabled", virtualServer.IsEnabled)
+	d.Set("ip_address", virtualServer.IPAddress)
+	d.Set("pool", virtualServer.Pool)
+	d.Set("logging", virtualServer.Logging)
+	d.Set("service_profile", flattenLBServiceProfiles(virtualServer.ServiceProfile))
+
+	if virtualServer.Interface != nil {
+		interfaceName := virtualServer.Interface.Name
+		if gatewayInterface := findGatewayInterfaceByHREF(edgeGateway.EdgeGateway, virtualServer.Interface.HREF); gatewayInterface != nil {
+			interfaceName = gatewayInterface.Network.Name
+		}
+		d.Set("interface", interfaceName)
+	}
+
+	return nil
+}
+
+// resourceVcdLBVirtualServerImport accepts "edge-gateway/virtual-server-name"
+func resourceVcdLBVirtualServerImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<virtual-server-name>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
+
+	err = resourceVcdLBVirtualServerRead(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("load balancer virtual server %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// expandLBVirtualServer sets the configuration of the resource on the
+// virtual server, the pool has to exist on the same edge gateway
+func expandLBVirtualServer(d *schema.ResourceData, edgeGateway *types.EdgeGateway, service *loadBalancerService, virtualServer *lbVirtualServer) error {
+	poolName := d.Get("pool").(string)
+	if findLBPool(service, poolName) == nil {
+		return fmt.Errorf("The edge gateway '%s' has no load balancer pool '%s'", edgeGateway.Name, poolName)
+	}
+
+	interfaceName := d.Get("interface").(string)
+	gatewayInterface := findGatewayInterface(edgeGateway, interfaceName)
+	if gatewayInterface == nil {
+		return fmt.Errorf("The edge gateway '%s' has no interface on the network '%s'", edgeGateway.Name, interfaceName)
+	}
+
+	serviceProfiles, err := expandLBServiceProfiles(d.Get("service_profile").([]interface{}))
+	if err != nil {
+		return err
+	}
+
+	virtualServer.IsEnabled = d.Get("enabled").(bool)
+	virtualServer.Description = d.Get("description").(string)
+	virtualServer.Interface = &types.Reference{
+		HREF: gatewayInterface.Network.HREF,
+		Name: gatewayInterface.Network.Name,
+		Type: gatewayInterface.Network.Type,
+	}
+	virtualServer.IPAddress = d.Get("ip_address").(string)
+	virtualServer.Pool = poolName
+	virtualServer.Logging = d.Get("logging").(bool)
+	virtualServer.ServiceProfile = mergeLBServiceProfiles(virtualServer.ServiceProfile, serviceProfiles)
+
+	return nil
+}
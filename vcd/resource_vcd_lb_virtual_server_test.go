@@ -0,0 +1,101 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdLBVirtualServerDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_lb_virtual_server" {
+			continue
+		}
+
+		_, services, err := testAccReadEdgeGatewayServices(rs)
+		if err != nil {
+			return err
+		}
+
+		if services.LoadBalancerService != nil && findLBVirtualServer(services.LoadBalancerService, rs.Primary.ID) != nil {
+			return fmt.Errorf("Load balancer virtual server %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdLBVirtualServer_Basic(t *testing.T) {
+	edgeGateway := os.Getenv("VCD_EDGE_GATEWAY")
+	externalNetwork := os.Getenv("VCD_EXTERNAL_NETWORK")
+	externalIP := os.Getenv("VCD_EXTERNAL_IP")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckExternalNetwork(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLBVirtualServerDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLBVirtualServer_basic, edgeGateway, externalNetwork, externalIP, 6443),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.test-server", "name", "terraform-test-server"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.test-server", "interface", externalNetwork),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.test-server", "ip_address", externalIP),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.test-server", "pool", "terraform-test-server"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.test-server", "service_profile.0.port", "6443"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_lb_virtual_server.test-server",
+				ImportState:       true,
+				ImportStateId:     edgeGateway + "/terraform-test-server",
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLBVirtualServer_basic, edgeGateway, externalNetwork, externalIP, 8443),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.test-server", "service_profile.0.port", "8443"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdLBVirtualServer_basic = `
+resource "vcd_lb_pool" "test-pool" {
+  edge_gateway = "%[1]s"
+  name         = "terraform-test-server"
+
+  service_port {
+    protocol  = "TCP"
+    algorithm = "ROUND_ROBIN"
+    port      = 6443
+  }
+
+  member {
+    ip_address = "10.254.0.11"
+  }
+}
+
+resource "vcd_lb_virtual_server" "test-server" {
+  edge_gateway = "%[1]s"
+  name         = "terraform-test-server"
+  interface    = "%[2]s"
+  ip_address   = "%[3]s"
+  pool         = "${vcd_lb_pool.test-pool.name}"
+
+  service_profile {
+    protocol = "TCP"
+    port     = %[4]d
+  }
+}
+`
@@ -0,0 +1,214 @@
+package vcd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+)
+
+// resourceVcdNat1to1 manages the SNAT and DNAT rules, and the firewall rules
+// allowing their traffic, mapping an internal IP to an external IP
+func resourceVcdNat1to1() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdNat1to1Create,
+		Delete: resourceVcdNat1to1Delete,
+		Read:   resourceVcdNat1to1Read,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdNat1to1Import,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"internal_ip": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: ValidateIPv4(),
+			},
+
+			"external_ip": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: ValidateIPv4(),
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceVcdNat1to1Create(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	internalIP := d.Get("internal_ip").(string)
+	externalIP := d.Get("external_ip").(string)
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	serviceConfiguration := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration
+	if serviceConfiguration == nil || serviceConfiguration.NatService == nil || serviceConfiguration.FirewallService == nil {
+		return fmt.Errorf("The edge gateway '%s' has no NAT or firewall service", edgeGateway.EdgeGateway.Name)
+	}
+
+	// A mapping created outside of Terraform is not taken over. The half
+	// left of a mapping broken out of band is not removed either, the rules
+	// removed with it may belong to other resources.
+	snat, dnat := find1to1NatRules(serviceConfiguration.NatService.NatRule, internalIP, externalIP)
+	if snat != nil && dnat != nil {
+		return fmt.Errorf("The edge gateway '%s' already maps %s to %s", edgeGateway.EdgeGateway.Name, internalIP, externalIP)
+	}
+	if snat != nil || dnat != nil {
+		return fmt.Errorf("The edge gateway '%s' has a half-created mapping of %s to %s, remove its remaining "+
+			"NAT rule from the edge gateway, or import it as a vcd_snat or vcd_dnat resource and destroy it, "+
+			"before creating the mapping", edgeGateway.EdgeGateway.Name, internalIP, externalIP)
+	}
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return edgeGateway.Create1to1Mapping(internalIP, externalIP, d.Get("description").(string))
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(externalIP)
+
+	return resourceVcdNat1to1Read(d, meta)
+}
+
+func resourceVcdNat1to1Read(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	snat, dnat := find1to1NatRules(edgeGatewayNatRules(edgeGateway.EdgeGateway), d.Get("internal_ip").(string), d.Id())
+	if snat == nil && dnat == nil {
+		log.Printf("[DEBUG] 1:1 NAT of %s no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if snat == nil || dnat == nil {
+		// The mapping is replaced when a half of it is missing, the external
+		// IP is cleared to force it. Delete removes the remaining half by the
+		// id of the resource, which is the external IP.
+		log.Printf("[DEBUG] 1:1 NAT of %s is missing its SNAT or DNAT rule. Replacing it", d.Id())
+		d.Set("external_ip", "")
+		return nil
+	}
+
+	d.Set("internal_ip", dnat.GatewayNatRule.TranslatedIP)
+	d.Set("external_ip", dnat.GatewayNatRule.OriginalIP)
+	d.Set("description", dnat.Description)
+
+	return nil
+}
+
+// resourceVcdNat1to1Import accepts "edge-gateway/internal-ip/external-ip"
+func resourceVcdNat1to1Import(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 3, "<edge-gateway>/<internal-ip>/<external-ip>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.Set("internal_ip", parts[1])
+	d.SetId(parts[2])
+
+	err = resourceVcdNat1to1Read(d, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Id() == "" {
+		return nil, fmt.Errorf("1:1 NAT of %s to %s not found on edge gateway %s", parts[1], parts[2], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVcdNat1to1Delete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	// Multiple VCD components need to run operations on the Edge Gateway, as
+	// the edge gatway will throw back an error if it is already performing an
+	// operation we must wait until we can aquire a lock on the client
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	edgeGateway, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	return remove1to1Mapping(ctx, &edgeGateway, d.Get("internal_ip").(string), d.Id())
+}
+
+// remove1to1Mapping removes the NAT and firewall rules of the mapping, the
+// caller holds the lock of the client
+func remove1to1Mapping(ctx context.Context, edgeGateway *govcd.EdgeGateway, internalIP, externalIP string) error {
+	err := retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return edgeGateway.Remove1to1Mapping(internalIP, externalIP)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
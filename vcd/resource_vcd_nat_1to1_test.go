@@ -0,0 +1,79 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdNat1to1Destroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_nat_1to1" {
+			continue
+		}
+
+		_, services, err := testAccReadEdgeGatewayServices(rs)
+		if err != nil {
+			return err
+		}
+		if services.NatService == nil {
+			continue
+		}
+
+		snat, dnat := find1to1NatRules(services.NatService.NatRule, rs.Primary.Attributes["internal_ip"], rs.Primary.ID)
+		if snat != nil || dnat != nil {
+			return fmt.Errorf("1:1 NAT of %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdNat1to1_Basic(t *testing.T) {
+	edgeGateway := os.Getenv("VCD_EDGE_GATEWAY")
+	externalIP := os.Getenv("VCD_EXTERNAL_IP")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckExternalNetwork(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdNat1to1Destroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdNat1to1_basic, edgeGateway, externalIP, "terraform-test"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_nat_1to1.test-nat", "internal_ip", "10.254.0.10"),
+					resource.TestCheckResourceAttr(
+						"vcd_nat_1to1.test-nat", "external_ip", externalIP),
+					resource.TestCheckResourceAttr(
+						"vcd_nat_1to1.test-nat", "description", "terraform-test"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_nat_1to1.test-nat",
+				ImportState:       true,
+				ImportStateId:     edgeGateway + "/10.254.0.10/" + externalIP,
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdNat1to1_basic, edgeGateway, externalIP, "terraform-test-updated"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_nat_1to1.test-nat", "description", "terraform-test-updated"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdNat1to1_basic = `
+resource "vcd_nat_1to1" "test-nat" {
+  edge_gateway = "%s"
+  internal_ip  = "10.254.0.10"
+  external_ip  = "%s"
+  description  = "%s"
+}
+`
@@ -10,16 +10,33 @@ import (
 	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	govcd "github.com/kublr/govcloudair"
 	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
 )
 
 func resourceVcdNetwork() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVcdNetworkCreate,
 		Read:   resourceVcdNetworkRead,
+		Update: resourceVcdNetworkUpdate,
 		Delete: resourceVcdNetworkDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		CustomizeDiff: resourceVcdNetworkCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(resourceTimeout),
+			Update: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
@@ -30,12 +47,24 @@ func resourceVcdNetwork() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 				ForceNew: true,
-				Default:  "natRouted",
+				Default:  types.FenceModeNAT,
+				ValidateFunc: validation.StringInSlice([]string{
+					types.FenceModeNAT, types.FenceModeIsolated, types.FenceModeBridged,
+				}, false),
 			},
 
+			// Only a natRouted network is connected to an edge gateway
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			// The external network a bridged network is connected to, either
+			// its name or its HREF
+			"parent_network": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
 				ForceNew: true,
 			},
 
@@ -48,28 +77,25 @@ func resourceVcdNetwork() *schema.Resource {
 
 			"gateway": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
 				ForceNew: true,
 			},
 
 			"dns1": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
 				Default:  "8.8.8.8",
 			},
 
 			"dns2": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
 				Default:  "8.8.4.4",
 			},
 
 			"dns_suffix": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
 			},
 
 			"href": &schema.Schema{
@@ -83,7 +109,6 @@ func resourceVcdNetwork() *schema.Resource {
 				Type:     schema.TypeBool,
 				Optional: true,
 				Default:  false,
-				ForceNew: true,
 			},
 
 			"dhcp_pool": &schema.Schema{
@@ -120,7 +145,6 @@ func resourceVcdNetwork() *schema.Resource {
 			"static_ip_pool": &schema.Schema{
 				Type:     schema.TypeSet,
 				Optional: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"start_address": &schema.Schema{
@@ -136,144 +160,324 @@ func resourceVcdNetwork() *schema.Resource {
 				},
 				Set: resourceVcdNetworkIPAddressHash,
 			},
+			"metadata":       MetadataSchema(),
+			"metadata_entry": MetadataEntrySchema(),
 		},
 	}
 }
 
 func resourceVcdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] CLIENT: %#v", vcdClient)
 	vcdClient.Mutex.Lock()
 	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
-
-	ipRanges := expandIPRange(d.Get("static_ip_pool").(*schema.Set).List())
+	fenceMode := d.Get("fence_mode").(string)
 
 	newnetwork := &types.OrgVDCNetwork{
 		Xmlns: "http://www.vmware.com/vcloud/v1.5",
 		Name:  d.Get("name").(string),
 		Configuration: &types.NetworkConfiguration{
-			FenceMode: d.Get("fence_mode").(string),
-			IPScopes: &types.IPScopes{
-				IPScope: []*types.IPScope{{
-					IsInherited: false,
-					Gateway:     d.Get("gateway").(string),
-					Netmask:     d.Get("netmask").(string),
-					DNS1:        d.Get("dns1").(string),
-					DNS2:        d.Get("dns2").(string),
-					DNSSuffix:   d.Get("dns_suffix").(string),
-					IPRanges:    &ipRanges,
-				}},
-			},
+			FenceMode:                 fenceMode,
 			BackwardCompatibilityMode: true,
 		},
-		EdgeGateway: &types.Reference{
-			HREF: edgeGateway.EdgeGateway.HREF,
-		},
 		IsShared: d.Get("shared").(bool),
 	}
 
+	// A bridged network inherits the IP settings of its parent
+	if fenceMode != types.FenceModeBridged {
+		ipRanges := expandIPRange(d.Get("static_ip_pool").(*schema.Set).List())
+		newnetwork.Configuration.IPScopes = &types.IPScopes{
+			IPScope: []*types.IPScope{{
+				IsInherited: false,
+				Gateway:     d.Get("gateway").(string),
+				Netmask:     d.Get("netmask").(string),
+				DNS1:        d.Get("dns1").(string),
+				DNS2:        d.Get("dns2").(string),
+				DNSSuffix:   d.Get("dns_suffix").(string),
+				IPRanges:    &ipRanges,
+			}},
+		}
+	}
+
+	var edgeGateway govcd.EdgeGateway
+	switch fenceMode {
+	case types.FenceModeNAT:
+		edgeGateway, err = vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+		if err != nil {
+			return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		}
+		newnetwork.EdgeGateway = &types.Reference{
+			HREF: edgeGateway.EdgeGateway.HREF,
+		}
+	case types.FenceModeIsolated:
+		// An isolated network serves its DHCP pools itself
+		if dhcp := d.Get("dhcp_pool").(*schema.Set); dhcp.Len() > 0 {
+			newnetwork.ServiceConfig = &types.GatewayFeatures{
+				GatewayDhcpService: expandNetworkDhcpService(dhcp.List()),
+			}
+		}
+	case types.FenceModeBridged:
+		newnetwork.Configuration.ParentNetwork, err = findExternalNetwork(&vcdClient.Client, d.Get("parent_network").(string))
+		if err != nil {
+			return err
+		}
+	}
+
 	log.Printf("[INFO] NETWORK: %#v", newnetwork)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		return resource.RetryableError(vcdClient.OrgVdc.CreateOrgVDCNetwork(newnetwork))
+	err = retryCall(ctx, func() *resource.RetryError {
+		return retryError(vdc.CreateOrgVDCNetwork(newnetwork))
 	})
 	if err != nil {
-		return fmt.Errorf("Error: %#v", err)
+		return fmt.Errorf("Error: %s", err)
 	}
 
-	err = vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
-	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Get("name").(string))
+	network, err := vdc.FindVDCNetwork(d.Get("name").(string))
 	if err != nil {
 		return fmt.Errorf("Error finding network: %#v", err)
 	}
 
-	if dhcp, ok := d.GetOk("dhcp_pool"); ok {
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-			task, err := edgeGateway.AddDhcpPool(network.OrgVDCNetwork, dhcp.(*schema.Set).List())
+	if dhcp, ok := d.GetOk("dhcp_pool"); ok && fenceMode == types.FenceModeNAT {
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return edgeGateway.AddDhcpPool(network.OrgVDCNetwork, dhcp.(*schema.Set).List())
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %s", err)
+		}
+
+	}
+
+	d.SetId(d.Get("name").(string))
+
+	err = configureMetadata(d, network.OrgVDCNetwork.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdNetworkRead(d, meta)
+}
+
+func resourceVcdNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutUpdate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	err = vdc.Refresh()
+	if err != nil {
+		return fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	network, err := vdc.FindVDCNetwork(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error finding network: %#v", err)
+	}
+
+	if d.HasChange("dns1") || d.HasChange("dns2") || d.HasChange("dns_suffix") ||
+		d.HasChange("shared") || d.HasChange("static_ip_pool") {
+		// vCD reconfigures the edge gateway of a routed network
+		vcdClient.Mutex.Lock()
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			err := network.Refresh()
 			if err != nil {
-				return resource.RetryableError(fmt.Errorf("Error adding DHCP pool: %#v", err))
+				return govcd.Task{}, errors.Wrapf(err, "cannot read network: %s", d.Id())
 			}
 
-			return resource.RetryableError(task.WaitTaskCompletion())
+			err = expandNetworkUpdate(d, network.OrgVDCNetwork)
+			if err != nil {
+				return govcd.Task{}, err
+			}
+
+			return updateOrgVDCNetwork(&vcdClient.Client, network.OrgVDCNetwork)
 		})
+		vcdClient.Mutex.Unlock()
 		if err != nil {
-			return fmt.Errorf("Error completing tasks: %#v", err)
+			return errors.Wrapf(err, "cannot update network: %s", d.Id())
 		}
-
 	}
 
-	d.SetId(d.Get("name").(string))
+	err = configureMetadata(d, network.OrgVDCNetwork.HREF, meta)
+	if err != nil {
+		return err
+	}
 
 	return resourceVcdNetworkRead(d, meta)
 }
 
 func resourceVcdNetworkRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[DEBUG] VCD Client configuration: %#v", vcdClient)
-	log.Printf("[DEBUG] VCD Client configuration: %#v", vcdClient.OrgVdc)
+	log.Printf("[DEBUG] VCD Client configuration: %#v", vdc)
 
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
-	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Id())
-	if err != nil {
+	network, err := vdc.FindVDCNetwork(d.Id())
+	if isNotFound(err) {
 		log.Printf("[DEBUG] Network no longer exists. Removing from tfstate")
 		d.SetId("")
 		return nil
 	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read network: %s", d.Id())
+	}
 
 	d.Set("name", network.OrgVDCNetwork.Name)
 	d.Set("href", network.OrgVDCNetwork.HREF)
+	d.Set("shared", network.OrgVDCNetwork.IsShared)
 	if c := network.OrgVDCNetwork.Configuration; c != nil {
 		d.Set("fence_mode", c.FenceMode)
-		if c.IPScopes != nil {
-			d.Set("gateway", c.IPScopes.IPScope[0].Gateway)
-			d.Set("netmask", c.IPScopes.IPScope[0].Netmask)
-			d.Set("dns1", c.IPScopes.IPScope[0].DNS1)
-			d.Set("dns2", c.IPScopes.IPScope[0].DNS2)
+		// The IP settings of a bridged network are not managed
+		if c.IPScopes != nil && len(c.IPScopes.IPScope) > 0 && c.FenceMode != types.FenceModeBridged {
+			ipScope := c.IPScopes.IPScope[0]
+			d.Set("gateway", ipScope.Gateway)
+			d.Set("netmask", ipScope.Netmask)
+			d.Set("dns1", ipScope.DNS1)
+			d.Set("dns2", ipScope.DNS2)
+			d.Set("dns_suffix", ipScope.DNSSuffix)
+
+			staticIPPool := make([]interface{}, 0)
+			if ipScope.IPRanges != nil {
+				for _, ipRange := range ipScope.IPRanges.IPRange {
+					staticIPPool = append(staticIPPool, map[string]interface{}{
+						"start_address": ipRange.StartAddress,
+						"end_address":   ipRange.EndAddress,
+					})
+				}
+			}
+			d.Set("static_ip_pool", staticIPPool)
+		}
+
+		parentNetwork := ""
+		if c.ParentNetwork != nil {
+			parentNetwork = c.ParentNetwork.Name
+			if isHREF(d.Get("parent_network").(string)) {
+				parentNetwork = c.ParentNetwork.HREF
+			}
+		}
+		d.Set("parent_network", parentNetwork)
+
+		if c.FenceMode == types.FenceModeIsolated {
+			d.Set("dhcp_pool", flattenNetworkDhcpPools(network.OrgVDCNetwork.ServiceConfig))
 		}
 	}
 
-	return nil
+	if network.OrgVDCNetwork.EdgeGateway != nil {
+		edgeGateway := govcd.NewEdgeGateway(&vcdClient.Client)
+		edgeGateway.EdgeGateway.HREF = network.OrgVDCNetwork.EdgeGateway.HREF
+		err = edgeGateway.Refresh()
+		if err != nil {
+			return fmt.Errorf("Error finding edge gateway: %#v", err)
+		}
+
+		d.Set("edge_gateway", edgeGateway.EdgeGateway.Name)
+		// The pools are left to vcd_edgegateway_dhcp_pool when the network
+		// has none
+		if d.Get("dhcp_pool").(*schema.Set).Len() > 0 {
+			d.Set("dhcp_pool", readDhcpPools(edgeGateway.EdgeGateway, network.OrgVDCNetwork.HREF))
+		}
+	}
+
+	return readMetadataAttributes(d, network.OrgVDCNetwork.HREF, meta)
+}
+
+// readDhcpPools returns the DHCP pools of the edge gateway which serve the
+// given network
+func readDhcpPools(edgeGateway *types.EdgeGateway, networkHREF string) []interface{} {
+	dhcpPools := make([]interface{}, 0)
+	if edgeGateway.Configuration == nil ||
+		edgeGateway.Configuration.EdgeGatewayServiceConfiguration == nil ||
+		edgeGateway.Configuration.EdgeGatewayServiceConfiguration.GatewayDhcpService == nil {
+		return dhcpPools
+	}
+
+	for _, pool := range edgeGateway.Configuration.EdgeGatewayServiceConfiguration.GatewayDhcpService.Pool {
+		if pool.Network == nil || pool.Network.HREF != networkHREF {
+			continue
+		}
+
+		dhcpPools = append(dhcpPools, map[string]interface{}{
+			"start_address":      pool.LowIPAddress,
+			"end_address":        pool.HighIPAddress,
+			"default_lease_time": pool.DefaultLeaseTime,
+			"max_lease_time":     pool.MaxLeaseTime,
+		})
+	}
+
+	return dhcpPools
 }
 
 func resourceVcdNetworkDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	vcdClient.Mutex.Lock()
 	defer vcdClient.Mutex.Unlock()
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
-	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Id())
+	network, err := vdc.FindVDCNetwork(d.Id())
 	if err != nil {
 		return fmt.Errorf("Error finding network: %#v", err)
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := network.Delete()
-		if err != nil {
-			return resource.RetryableError(
-				fmt.Errorf("Error Deleting Network: %#v", err))
-		}
-		return resource.RetryableError(task.WaitTaskCompletion())
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return network.Delete()
 	})
 	if err != nil {
-		return err
+		return errors.Wrapf(err, "cannot delete network: %s", d.Id())
 	}
 
 	return nil
 }
 
+// resourceVcdNetworkCustomizeDiff rejects the arguments which do not apply to
+// the fence mode of the network at plan time
+func resourceVcdNetworkCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("fence_mode") {
+		return nil
+	}
+
+	return validateNetworkArguments(d.Get("fence_mode").(string), func(key string) bool {
+		_, ok := d.GetOk(key)
+		return ok || !d.NewValueKnown(key)
+	})
+}
+
 func resourceVcdNetworkIPAddressHash(v interface{}) int {
 	var buf bytes.Buffer
 	m := v.(map[string]interface{})
@@ -2,18 +2,33 @@ package vcd
 
 import (
 	"fmt"
+	"log"
+	"strings"
 
-	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/kublr/govcloudair/types/v56"
 )
 
 func resourceVcdSNAT() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVcdSNATCreate,
+		Update: resourceVcdSNATUpdate,
 		Delete: resourceVcdSNATDelete,
 		Read:   resourceVcdSNATRead,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdSNATImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(edgeGatewayTimeout),
+			Update: schema.DefaultTimeout(edgeGatewayTimeout),
+			Delete: schema.DefaultTimeout(edgeGatewayTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
@@ -23,102 +38,136 @@ func resourceVcdSNAT() *schema.Resource {
 			"external_ip": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 
 			"internal_ip": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+			},
+
+			// The uplink interface is used when not set
+			"network_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
 			},
 		},
 	}
 }
 
 func resourceVcdSNATCreate(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
-
-	// Creating a loop to offer further protection from the edge gateway erroring
-	// due to being busy eg another person is using another client so wouldn't be
-	// constrained by out lock. If the edge gateway reurns with a busy error, wait
-	// 3 seconds and then try again. Continue until a non-busy error or success
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	err := changeNatRule(d, meta, func(edgeGateway *types.EdgeGateway) (*types.NatRule, error) {
+		return expandSNATRule(d, edgeGateway)
+	})
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		return err
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := edgeGateway.AddNATMapping("SNAT", d.Get("internal_ip").(string),
-			d.Get("external_ip").(string),
-			"any")
-		if err != nil {
-			return resource.RetryableError(fmt.Errorf("Error setting SNAT rules: %#v", err))
-		}
-		return resource.RetryableError(task.WaitTaskCompletion())
+	return resourceVcdSNATRead(d, meta)
+}
+
+func resourceVcdSNATUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := changeNatRule(d, meta, func(edgeGateway *types.EdgeGateway) (*types.NatRule, error) {
+		return expandSNATRule(d, edgeGateway)
 	})
 	if err != nil {
 		return err
 	}
 
-	d.SetId(d.Get("internal_ip").(string))
-	return nil
+	return resourceVcdSNATRead(d, meta)
 }
 
 func resourceVcdSNATRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	e, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
 
+	vdc, err := vcdClient.vdcFromResource(d)
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		return err
 	}
 
-	var found bool
-
-	for _, r := range e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService.NatRule {
-		if r.RuleType == "SNAT" &&
-			r.GatewayNatRule.OriginalIP == d.Id() {
-			found = true
-			d.Set("external_ip", r.GatewayNatRule.TranslatedIP)
-		}
+	e, err := vdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if isNotFound(err) {
+		log.Printf("[DEBUG] Edge gateway %s no longer exists. Removing from tfstate", d.Get("edge_gateway").(string))
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	if !found {
+	// The ids of rules created by earlier versions of the provider, and of
+	// rules imported by internal IP, are the internal IP
+	rule := findNatRule(edgeGatewayNatRules(e.EdgeGateway), "SNAT", d.Id(), func(r *types.GatewayNatRule) bool {
+		return strings.Contains(d.Id(), ".") && r.OriginalIP == d.Id()
+	})
+	if rule == nil {
+		log.Printf("[DEBUG] SNAT rule %s no longer exists. Removing from tfstate", d.Id())
 		d.SetId("")
+		return nil
 	}
 
+	d.SetId(rule.ID)
+	d.Set("internal_ip", rule.GatewayNatRule.OriginalIP)
+	d.Set("external_ip", rule.GatewayNatRule.TranslatedIP)
+	d.Set("network_name", natInterfaceName(e.EdgeGateway, rule.GatewayNatRule))
+	d.Set("description", rule.Description)
+	d.Set("enabled", rule.IsEnabled)
+
 	return nil
 }
 
-func resourceVcdSNATDelete(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
+// resourceVcdSNATImport accepts "edge-gateway/rule-id" or
+// "edge-gateway/internal-ip"
+func resourceVcdSNATImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), 2, "<edge-gateway>/<rule-id> or <edge-gateway>/<internal-ip>")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("edge_gateway", parts[0])
+	d.SetId(parts[1])
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	err = resourceVcdSNATRead(d, meta)
 	if err != nil {
-		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		return nil, err
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := edgeGateway.RemoveNATMapping("SNAT", d.Get("internal_ip").(string),
-			d.Get("external_ip").(string),
-			"")
-		if err != nil {
-			return resource.RetryableError(fmt.Errorf("Error setting SNAT rules: %#v", err))
-		}
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+	if d.Id() == "" {
+		return nil, fmt.Errorf("SNAT rule %s not found on edge gateway %s", parts[1], parts[0])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceVcdSNATDelete(d *schema.ResourceData, meta interface{}) error {
+	return changeNatRule(d, meta, nil)
+}
+
+func expandSNATRule(d *schema.ResourceData, edgeGateway *types.EdgeGateway) (*types.NatRule, error) {
+	gatewayInterface, err := natInterface(edgeGateway, d.Get("network_name").(string))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &types.NatRule{
+		Description: d.Get("description").(string),
+		RuleType:    "SNAT",
+		IsEnabled:   d.Get("enabled").(bool),
+		GatewayNatRule: &types.GatewayNatRule{
+			Interface:    gatewayInterface,
+			OriginalIP:   d.Get("internal_ip").(string),
+			TranslatedIP: d.Get("external_ip").(string),
+		},
+	}, nil
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/kublr/govcloudair"
 )
@@ -15,8 +14,20 @@ func resourceVcdVApp() *schema.Resource {
 		Update: resourceVcdVAppUpdate,
 		Read:   resourceVcdVAppRead,
 		Delete: resourceVcdVAppDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdVAppImport,
+		},
+		CustomizeDiff: resourceVcdVAppCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(deployTimeout),
+			Update: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -42,28 +53,84 @@ func resourceVcdVApp() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			// An empty vApp is composed when no template is given
+			"catalog_name": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnreadableAfterImport,
+			},
+			"template_name": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnreadableAfterImport,
+			},
+			"accept_all_eulas": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  true,
+			},
+			"ovf_properties": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"metadata":       MetadataSchema(),
+			"metadata_entry": MetadataEntrySchema(),
+			"power_on": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"vm": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     vAppVMSchema(),
+			},
 		},
 	}
 }
 
 func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
 	networks, err := createNetworkConfiguration(d, meta)
 	if err != nil {
 		return err
 	}
 
+	templateName := d.Get("template_name").(string)
+
 	// See if vApp exists
-	vapp, err := vcdClient.OrgVdc.GetVAppByHREF(d.Id())
+	vapp, err := vdc.GetVAppByHREF(d.Id())
 	log.Printf("[TRACE] Looking for existing vapp, found %#v", vapp)
 
-	if err != nil {
+	if err != nil && templateName != "" {
+		log.Printf("[TRACE] No vApp found, instantiating template")
+		instantiated, err := instantiateVAppTemplate(d, meta, networks)
+		if err != nil {
+			return err
+		}
+
+		vapp, err = vdc.GetVAppByHREF(instantiated.HREF)
+		if err != nil {
+			return fmt.Errorf("Error finding VApp: %#v", err)
+		}
+	} else if err != nil {
 		log.Printf("[TRACE] No vApp found, preparing creation")
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
-			task, err := vcdClient.OrgVdc.ComposeVApp(d.Get("name").(string), d.Get("description").(string), networks)
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+			task, err := vdc.ComposeVApp(d.Get("name").(string), d.Get("description").(string), networks)
 			if err == nil {
-				vapp, err = vcdClient.OrgVdc.GetVAppByHREF(task.Task.Owner.HREF)
+				vapp, err = vdc.GetVAppByHREF(task.Task.Owner.HREF)
 			}
 
 			return task, err
@@ -78,7 +145,7 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 
 	// Refresh vcd and vApp to get the new versions
 	log.Printf("[TRACE] Updating state from VCD")
-	err = vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
@@ -92,19 +159,40 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 	// This should be HREF, but FindVAppByHREF is buggy
 	d.SetId(vapp.VApp.HREF)
 
-	return nil
+	// The properties of a template are set by the instantiation
+	if templateName == "" {
+		err = configureOvfProperties(d, vapp.VApp.HREF, meta)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = configureMetadata(d, vapp.VApp.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdVAppRead(d, meta)
 }
 
 func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutUpdate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from VCD")
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
 	// Should be fetched by ID/HREF
-	vapp, err := vcdClient.OrgVdc.GetVAppByHREF(d.Id())
+	vapp, err := vdc.GetVAppByHREF(d.Id())
 
 	if err != nil {
 		return fmt.Errorf("Error finding VApp: %#v", err)
@@ -117,16 +205,11 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	// Update networks
 	if d.HasChange("description") {
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-			task, err := vapp.SetDescription(d.Get("description").(string))
-			if err != nil {
-				return resource.NonRetryableError(fmt.Errorf("Error setting description: %#v", err))
-			}
-
-			return resource.RetryableError(task.WaitTaskCompletion())
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+			return vapp.SetDescription(d.Get("description").(string))
 		})
 		if err != nil {
-			return fmt.Errorf("Error completing task: %#v", err)
+			return fmt.Errorf("Error completing task: %s", err)
 		}
 	}
 
@@ -137,37 +220,58 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 			return err
 		}
 
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-			task, err := vapp.SetNetworkConfigurations(networks)
-			if err != nil {
-				return resource.NonRetryableError(fmt.Errorf("Error setting network: %#v", err))
-			}
-
-			return resource.RetryableError(task.WaitTaskCompletion())
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+			return vapp.SetNetworkConfigurations(networks)
 		})
 		if err != nil {
-			return fmt.Errorf("Error completing task: %#v", err)
+			return fmt.Errorf("Error completing task: %s", err)
 		}
 	}
 
-	return nil
+	err = configureOvfProperties(d, vapp.VApp.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	err = configureMetadata(d, vapp.VApp.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("power_on") {
+		err = powerVApp(d, &vapp, meta)
+		if err != nil {
+			return err
+		}
+	}
+
+	return resourceVcdVAppRead(d, meta)
 }
 
 func resourceVcdVAppRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from VCD")
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
 	// Should be fetched by ID/HREF
-	_, err = vcdClient.OrgVdc.GetVAppByHREF(d.Id())
-	if err != nil {
+	_, err = vdc.GetVAppByHREF(d.Id())
+	if isGoneByHREF(err) {
 		log.Printf("[DEBUG] Unable to find vapp. Removing from tfstate")
 		d.SetId("")
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("Error finding vApp: %s", err)
+	}
 
 	err = readVApp(d, meta)
 
@@ -178,16 +282,59 @@ func resourceVcdVAppRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// resourceVcdVAppCustomizeDiff rejects the template arguments which are not
+// set together at plan time
+func resourceVcdVAppCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// The vApp is only powered on at creation when it is instantiated
+	powerOn := d.Id() == "" && d.NewValueKnown("power_on") && d.Get("power_on").(bool)
+
+	return validateVAppTemplateArguments(powerOn, func(key string) bool {
+		_, ok := d.GetOk(key)
+		return ok || !d.NewValueKnown(key)
+	})
+}
+
+// resourceVcdVAppImport accepts either the vApp HREF or its name
+func resourceVcdVAppImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if isHREF(d.Id()) {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	vapp, err := vdc.FindVAppByName(d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("Error finding VApp (%s): %#v", d.Id(), err)
+	}
+
+	d.SetId(vapp.VApp.HREF)
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceVcdVAppDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from VCD")
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
 	// Should be fetched by ID/HREF
-	vapp, err := vcdClient.OrgVdc.GetVAppByHREF(d.Id())
+	vapp, err := vdc.GetVAppByHREF(d.Id())
 
 	if err != nil {
 		return fmt.Errorf("Error finding VApp: %#v", err)
@@ -198,22 +345,12 @@ func resourceVcdVAppDelete(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting VApp status: %#v, %s", err, status)
 	}
 
-	_ = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := vapp.Undeploy()
-		if err != nil {
-			return resource.NonRetryableError(fmt.Errorf("Error undeploying: %#v", err))
-		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
+	_ = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+		return vapp.Undeploy()
 	})
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := vapp.Delete()
-		if err != nil {
-			return resource.NonRetryableError(fmt.Errorf("Error deleting: %#v", err))
-		}
-
-		return resource.RetryableError(task.WaitTaskCompletion())
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+		return vapp.Delete()
 	})
 
 	if err != nil {
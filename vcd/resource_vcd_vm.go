@@ -15,8 +15,20 @@ func resourceVcdVM() *schema.Resource {
 		Update: resourceVcdVMUpdate,
 		Read:   resourceVcdVMRead,
 		Delete: resourceVcdVMDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdVMImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(deployTimeout),
+			Update: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -26,13 +38,15 @@ func resourceVcdVM() *schema.Resource {
 				Optional: true,
 			},
 			"catalog_name": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				DiffSuppressFunc: suppressUnreadableAfterImport,
 			},
 			"template_name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressUnreadableAfterImport,
 			},
 			"memory": {
 				Type:     schema.TypeInt,
@@ -50,6 +64,15 @@ func resourceVcdVM() *schema.Resource {
 					Schema: VirtualMachineNetworkSubresourceSchema(),
 				},
 			},
+			"internal_disk": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+
+				Elem: &schema.Resource{
+					Schema: VirtualMachineInternalDiskSubresourceSchema(),
+				},
+			},
 			"initscript": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -76,35 +99,71 @@ func resourceVcdVM() *schema.Resource {
 			"storage_profile": {
 				Type:     schema.TypeString,
 				Optional: true,
+				Computed: true,
 			},
+			// Only sent when they are set, the customization block manages
+			// the same settings
 			"admin_password_enabled": {
-				Type:     schema.TypeBool,
-				Optional: true,
-				Default:  true,
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"customization"},
+				Deprecated:    "Use customization.admin_password_enabled instead",
 			},
 			"admin_password_auto": {
-				Type:     schema.TypeBool,
-				Optional: true,
-				Default:  true,
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"customization"},
+				Deprecated:    "Use customization.admin_password_auto instead",
 			},
 			"admin_password": {
-				Type:     schema.TypeString,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"customization"},
+				Deprecated:    "Use customization.admin_password instead",
+			},
+			"customization": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				MaxItems: 1,
+
+				Elem: &schema.Resource{
+					Schema: VirtualMachineCustomizationSubresourceSchema(),
+				},
+			},
+			"ovf_properties": {
+				Type:     schema.TypeMap,
 				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
+			"metadata":       MetadataSchema(),
+			"metadata_entry": MetadataEntrySchema(),
 		},
 	}
 }
 
 func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from VCD")
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
 	// Should be fetched by ID/HREF
-	vapp, err := vcdClient.OrgVdc.GetVAppByHREF(d.Get("vapp_href").(string))
+	vapp, err := vdc.GetVAppByHREF(d.Get("vapp_href").(string))
 	if err != nil {
 		return fmt.Errorf("Error finding VApp: %#v", err)
 	}
@@ -125,12 +184,12 @@ func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error refreshing vApp: %#v", err)
 	}
 
-	err = retryCallWithVAppErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+	err = retryCallWithVAppErrorHandling(ctx, func() (govcloudair.Task, error) {
 		return vapp.AddVMs([]*types.SourcedCompositionItemParam{sourceItem})
 	})
 
 	if err != nil {
-		return fmt.Errorf("Error completing task: %#v", err)
+		return fmt.Errorf("Error completing task: %s", err)
 	}
 
 	vm, err := vapp.GetVmByName(d.Get("name").(string))
@@ -150,7 +209,7 @@ func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	log.Printf("[DEBUG] (%s) Sending reconfiguration event to VCD", vm.VM.Name)
-	err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 		return vm.Reconfigure()
 	})
 	if err != nil {
@@ -164,6 +223,24 @@ func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	log.Printf("[DEBUG] (%s) Configuring internal disks", vm.VM.Name)
+	err = configureInternalDisks(d, vm, meta)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] (%s) Configuring OVF properties", vm.VM.Name)
+	err = configureOvfProperties(d, vm.VM.HREF, meta)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] (%s) Configuring metadata", vm.VM.Name)
+	err = configureMetadata(d, vm.VM.HREF, meta)
+	if err != nil {
+		return err
+	}
+
 	err = readVM(d, meta)
 
 	if err != nil {
@@ -177,7 +254,7 @@ func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 
 	if d.Get("power_on").(bool) && status != types.VAppStatuses[4] {
 		log.Printf("[DEBUG] (%s) Powering on VM after Create", vm.VM.Name)
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 			return vm.PowerOn()
 		})
 		if err != nil {
@@ -185,7 +262,7 @@ func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 		}
 	} else if !d.Get("power_on").(bool) && status != types.VAppStatuses[8] {
 		log.Printf("[DEBUG] (%s) Powering off VM after Create", vm.VM.Name)
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 			return vm.PowerOff()
 		})
 		if err != nil {
@@ -198,9 +275,16 @@ func resourceVcdVMCreate(d *schema.ResourceData, meta interface{}) error {
 
 func resourceVcdVMUpdate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutUpdate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
 
 	// Get VM object from VCD
-	vm, err := vcdClient.OrgVdc.GetVMByHREF(d.Get("href").(string))
+	vm, err := vdc.GetVMByHREF(d.Get("href").(string))
 
 	if err != nil {
 		return fmt.Errorf("Could not find VM (%s)(%s) in VCD", d.Get("name").(string), d.Get("href").(string))
@@ -211,49 +295,70 @@ func resourceVcdVMUpdate(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting vm status: %#v, %s", err, status)
 	}
 
-	if status != types.VAppStatuses[8] {
-		log.Printf("[DEBUG] (%s) Powering off VM for reconfiguring", vm.VM.Name)
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
-			return vm.PowerOff()
-		})
+	// The metadata and the OVF properties are changed with the VM running
+	if needsVMReconfiguration(d) {
+		if status != types.VAppStatuses[8] {
+			log.Printf("[DEBUG] (%s) Powering off VM for reconfiguring", vm.VM.Name)
+			err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+				return vm.PowerOff()
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// If a network interface adapter has changed, we need to remove it and
+		// add it again. GJ VMware.
+		if d.HasChange("network") {
+			vm.VM.NetworkConnectionSection.NetworkConnection = []*types.NetworkConnection{}
+			// vm.VM.VirtualHardwareSection.Item = []*types.VirtualHardwareItem{}
+			vm.RemoveVirtualHardwareItemByResourceType(types.ResourceTypeEthernet)
+
+			log.Printf("[DEBUG] (%s) Sending reconfiguration event to VCD to remove nics", vm.VM.Name)
+			err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
+				return vm.Reconfigure()
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = configureVM(d, &vm, meta)
+
 		if err != nil {
 			return err
 		}
-	}
-
-	// If a network interface adapter has changed, we need to remove it and
-	// add it again. GJ VMware.
-	if d.HasChange("network") {
-		vm.VM.NetworkConnectionSection.NetworkConnection = []*types.NetworkConnection{}
-		// vm.VM.VirtualHardwareSection.Item = []*types.VirtualHardwareItem{}
-		vm.RemoveVirtualHardwareItemByResourceType(types.ResourceTypeEthernet)
 
-		log.Printf("[DEBUG] (%s) Sending reconfiguration event to VCD to remove nics", vm.VM.Name)
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+		log.Printf("[DEBUG] (%s) Sending reconfiguration event to VCD", vm.VM.Name)
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 			return vm.Reconfigure()
 		})
 		if err != nil {
 			return err
 		}
-	}
 
-	err = configureVM(d, &vm, meta)
+		log.Printf("[TRACE] (%s) Starting configuration that needs separate requests", vm.VM.Name)
+		err = configureVMWorkaround(d, &vm, meta)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
+
+		log.Printf("[DEBUG] (%s) Configuring internal disks", vm.VM.Name)
+		err = configureInternalDisks(d, &vm, meta)
+		if err != nil {
+			return err
+		}
 	}
 
-	log.Printf("[DEBUG] (%s) Sending reconfiguration event to VCD", vm.VM.Name)
-	err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
-		return vm.Reconfigure()
-	})
+	log.Printf("[DEBUG] (%s) Configuring OVF properties", vm.VM.Name)
+	err = configureOvfProperties(d, vm.VM.HREF, meta)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("[TRACE] (%s) Starting configuration that needs separate requests", vm.VM.Name)
-	err = configureVMWorkaround(d, &vm, meta)
-
+	log.Printf("[DEBUG] (%s) Configuring metadata", vm.VM.Name)
+	err = configureMetadata(d, vm.VM.HREF, meta)
 	if err != nil {
 		return err
 	}
@@ -269,9 +374,14 @@ func resourceVcdVMUpdate(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting vm status: %#v, %s", err, status)
 	}
 
-	if d.Get("power_on").(bool) && status != types.VAppStatuses[4] {
+	if d.Get("power_on").(bool) && needsRecustomization(d) {
+		err = powerOnWithCustomization(ctx, &vm, meta)
+		if err != nil {
+			return err
+		}
+	} else if d.Get("power_on").(bool) && status != types.VAppStatuses[4] {
 		log.Printf("[DEBUG] (%s) Powering on VM after Update", vm.VM.Name)
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 			return vm.PowerOn()
 		})
 		if err != nil {
@@ -279,7 +389,7 @@ func resourceVcdVMUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 	} else if !d.Get("power_on").(bool) && status != types.VAppStatuses[8] {
 		log.Printf("[DEBUG] (%s) Powering off VM after Update", vm.VM.Name)
-		err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+		err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 			return vm.PowerOff()
 		})
 		if err != nil {
@@ -300,16 +410,62 @@ func resourceVcdVMRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// resourceVcdVMImport accepts either the VM HREF or a "vapp-name/vm-name" pair
+func resourceVcdVMImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var vm govcloudair.VM
+	if isHREF(d.Id()) {
+		vm, err = vdc.GetVMByHREF(d.Id())
+		if err != nil {
+			return nil, fmt.Errorf("Error finding VM (%s): %#v", d.Id(), err)
+		}
+	} else {
+		parts, err := splitImportID(d.Id(), 2, "<vm-href> or <vapp-name>/<vm-name>")
+		if err != nil {
+			return nil, err
+		}
+
+		vapp, err := vdc.FindVAppByName(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("Error finding VApp (%s): %#v", parts[0], err)
+		}
+
+		vm, err = vdc.FindVMByName(vapp, parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("Error finding VM (%s) in VApp (%s): %#v", parts[1], parts[0], err)
+		}
+	}
+
+	d.SetId(vm.VM.HREF)
+	d.Set("href", vm.VM.HREF)
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceVcdVMDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("[TRACE] Updating state from VCD")
-	err := vcdClient.OrgVdc.Refresh()
+	err = vdc.Refresh()
 	if err != nil {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
 	// Should be fetched by ID/HREF
-	vapp, err := vcdClient.OrgVdc.GetVAppByHREF(d.Get("vapp_href").(string))
+	vapp, err := vdc.GetVAppByHREF(d.Get("vapp_href").(string))
 	if err != nil {
 		return fmt.Errorf("Error finding VApp: %#v", err)
 	}
@@ -320,7 +476,7 @@ func resourceVcdVMDelete(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error refreshing vApp: %#v", err)
 	}
 
-	vm, err := vcdClient.OrgVdc.GetVMByHREF(d.Id())
+	vm, err := vdc.GetVMByHREF(d.Id())
 
 	if err != nil {
 		return err
@@ -334,7 +490,7 @@ func resourceVcdVMDelete(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[TRACE] (%s) VM has status: %s", d.Get("name").(string), status)
 	log.Printf("[DEBUG] (%s) Undeploying VM", vm.VM.Name)
-	err = retryCallWithBusyEntityErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcloudair.Task, error) {
 		return vm.Undeploy(types.UndeployPowerActionPowerOff)
 	})
 	// if err != nil {
@@ -354,7 +510,7 @@ func resourceVcdVMDelete(d *schema.ResourceData, meta interface{}) error {
 	// }
 
 	log.Printf("[TRACE] (%s) Sending remove request to VCD", d.Get("name").(string))
-	err = retryCallWithVAppErrorHandling(vcdClient.MaxRetryTimeout, func() (govcloudair.Task, error) {
+	err = retryCallWithVAppErrorHandling(ctx, func() (govcloudair.Task, error) {
 		return vapp.RemoveVMs([]*types.VM{vm.VM})
 	})
 	if err != nil {
@@ -0,0 +1,273 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/kublr/govcloudair"
+	"github.com/kublr/govcloudair/types/v56"
+	"github.com/pkg/errors"
+)
+
+// createSnapshotParams are the parameters of the createSnapshot action of a
+// VM, they are not part of the vendored types
+type createSnapshotParams struct {
+	XMLName     xml.Name `xml:"CreateSnapshotParams"`
+	Xmlns       string   `xml:"xmlns,attr"`
+	Memory      bool     `xml:"memory,attr"`
+	Quiesce     bool     `xml:"quiesce,attr"`
+	Name        string   `xml:"name,attr,omitempty"`
+	Description string   `xml:"Description,omitempty"`
+}
+
+// resourceVcdVMSnapshot manages the snapshot of a VM, vCD keeps a single
+// snapshot per VM so the snapshot is identified by the HREF of its VM
+func resourceVcdVMSnapshot() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdVMSnapshotCreate,
+		Read:   resourceVcdVMSnapshotRead,
+		Update: resourceVcdVMSnapshotUpdate,
+		Delete: resourceVcdVMSnapshotDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdVMSnapshotImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(resourceTimeout),
+			Update: schema.DefaultTimeout(resourceTimeout),
+			Delete: schema.DefaultTimeout(resourceTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org": orgSchema(true),
+			"vdc": vdcSchema(true),
+			"vm_href": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"memory": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			"quiesce": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  false,
+			},
+			// Any change reverts the VM to the snapshot
+			"revert_trigger": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"powered_on": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVcdVMSnapshotCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutCreate)
+	defer cancel()
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	vmHREF := d.Get("vm_href").(string)
+
+	vm, err := vdc.GetVMByHREF(vmHREF)
+	if err != nil {
+		return errors.Wrapf(err, "cannot find vm: vmHREF=%s", vmHREF)
+	}
+
+	params := &createSnapshotParams{
+		Xmlns:   types.XMLNamespaceXMLNS,
+		Memory:  d.Get("memory").(bool),
+		Quiesce: d.Get("quiesce").(bool),
+	}
+
+	log.Printf("[INFO] Create snapshot of VM '%s'", vm.VM.Name)
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return vmSnapshotAction(&vcdClient.Client, vmHREF, "createSnapshot", "application/vnd.vmware.vcloud.createSnapshotParams+xml", params)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot create snapshot: vmHREF=%s", vmHREF)
+	}
+
+	d.SetId(vmHREF)
+
+	return resourceVcdVMSnapshotRead(d, meta)
+}
+
+func resourceVcdVMSnapshotRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.vdcFromResource(d)
+	if err != nil {
+		return err
+	}
+
+	_, err = vdc.GetVMByHREF(d.Id())
+	if isGoneByHREF(err) {
+		log.Printf("[DEBUG] VM '%s' no longer exists. Removing snapshot from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read VM: %s", d.Id())
+	}
+
+	snapshot, err := readVMSnapshot(&vcdClient.Client, d.Id())
+	if err != nil {
+		return err
+	}
+
+	// A new snapshot replaces the one of the state
+	created := d.Get("created").(string)
+	if snapshot == nil || (created != "" && snapshot.Created != created) {
+		log.Printf("[DEBUG] Snapshot of VM '%s' no longer exists. Removing from tfstate", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("vm_href", d.Id())
+	d.Set("created", snapshot.Created)
+	d.Set("size", snapshot.Size)
+	d.Set("powered_on", snapshot.PoweredOn)
+
+	return nil
+}
+
+func resourceVcdVMSnapshotUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutUpdate)
+	defer cancel()
+
+	if d.HasChange("revert_trigger") {
+		log.Printf("[INFO] Revert VM '%s' to its snapshot", d.Id())
+
+		err := retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+			return vmSnapshotAction(&vcdClient.Client, d.Id(), "revertToCurrentSnapshot", "", nil)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "cannot revert to snapshot: vmHREF=%s", d.Id())
+		}
+	}
+
+	return resourceVcdVMSnapshotRead(d, meta)
+}
+
+func resourceVcdVMSnapshotDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	ctx, cancel := vcdClient.operationContext(d, schema.TimeoutDelete)
+	defer cancel()
+
+	snapshot, err := readVMSnapshot(&vcdClient.Client, d.Id())
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		log.Printf("[DEBUG] VM '%s' has no snapshot. nothing to remove", d.Id())
+		return nil
+	}
+
+	log.Printf("[INFO] Remove snapshot of VM '%s'", d.Id())
+
+	err = retryCallWithBusyEntityErrorHandling(ctx, func() (govcd.Task, error) {
+		return vmSnapshotAction(&vcdClient.Client, d.Id(), "removeAllSnapshots", "", nil)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot remove snapshot: vmHREF=%s", d.Id())
+	}
+
+	return nil
+}
+
+// resourceVcdVMSnapshotImport imports the snapshot of the VM with the given
+// HREF, memory and quiesce are given as parameters of the HREF since they
+// cannot be read back
+func resourceVcdVMSnapshotImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vmHREF, memory, quiesce, err := parseVMSnapshotImportID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(vmHREF)
+	d.Set("vm_href", vmHREF)
+	d.Set("memory", memory)
+	d.Set("quiesce", quiesce)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseVMSnapshotImportID parses "<vm-href>[?memory=<bool>&quiesce=<bool>]",
+// the parameters default to false
+func parseVMSnapshotImportID(id string) (string, bool, bool, error) {
+	format := "<vm-href> or <vm-href>?memory=<bool>&quiesce=<bool>"
+	if !isHREF(id) {
+		return "", false, false, errors.Errorf("unexpected import ID %q, expected %s", id, format)
+	}
+
+	vmURL, err := url.Parse(id)
+	if err != nil {
+		return "", false, false, errors.Wrapf(err, "unexpected import ID %q, expected %s", id, format)
+	}
+
+	params := map[string]bool{"memory": false, "quiesce": false}
+	for name, values := range vmURL.Query() {
+		if _, ok := params[name]; !ok || len(values) != 1 {
+			return "", false, false, errors.Errorf("unexpected import ID %q, expected %s", id, format)
+		}
+		params[name], err = strconv.ParseBool(values[0])
+		if err != nil {
+			return "", false, false, errors.Errorf("unexpected import ID %q, expected %s", id, format)
+		}
+	}
+
+	vmURL.RawQuery = ""
+	return vmURL.String(), params["memory"], params["quiesce"], nil
+}
+
+// readVMSnapshot returns the current snapshot of the VM, nil if it has none
+func readVMSnapshot(client *govcd.Client, vmHREF string) (*types.SnapshotItem, error) {
+	section := &types.SnapshotSection{}
+	err := getXML(client, vmHREF+"/snapshotSection", section)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read snapshots: vmHREF=%s", vmHREF)
+	}
+
+	if len(section.Snapshot) == 0 {
+		return nil, nil
+	}
+	return section.Snapshot[len(section.Snapshot)-1], nil
+}
+
+func vmSnapshotAction(client *govcd.Client, vmHREF, action, contentType string, params interface{}) (govcd.Task, error) {
+	task := govcd.NewTask(client)
+	err := doXMLRequest(client, http.MethodPost, vmHREF+"/action/"+action, contentType, params, task.Task)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+	return *task, nil
+}
@@ -0,0 +1,154 @@
+package vcd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func testAccCheckVcdVMSnapshotDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_vm_snapshot" {
+			continue
+		}
+
+		_, err := conn.OrgVdc.GetVMByHREF(rs.Primary.ID)
+		if err != nil {
+			continue
+		}
+
+		snapshot, err := readVMSnapshot(&conn.Client, rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		if snapshot != nil {
+			return fmt.Errorf("Snapshot of VM %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func TestAccVcdVMSnapshot_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVMSnapshotDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCheckVcdVMSnapshot_basic0,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"vcd_vm_snapshot.test-snapshot", "vm_href", "vcd_vm.test-vm", "href"),
+					resource.TestCheckResourceAttr(
+						"vcd_vm_snapshot.test-snapshot", "memory", "false"),
+					resource.TestCheckResourceAttrSet(
+						"vcd_vm_snapshot.test-snapshot", "created"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:            "vcd_vm_snapshot.test-snapshot",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"revert_trigger"},
+			},
+			resource.TestStep{
+				Config: testAccCheckVcdVMSnapshot_basic1,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_vm_snapshot.test-snapshot", "revert_trigger", "2"),
+					resource.TestCheckResourceAttrSet(
+						"vcd_vm_snapshot.test-snapshot", "created"),
+				),
+			},
+		},
+	})
+}
+
+func TestParseVMSnapshotImportID(t *testing.T) {
+	href := "https://vcd.example.com/api/vApp/vm-1"
+
+	cases := []struct {
+		id      string
+		memory  bool
+		quiesce bool
+		err     bool
+	}{
+		{id: href},
+		{id: href + "?memory=true", memory: true},
+		{id: href + "?quiesce=true", quiesce: true},
+		{id: href + "?memory=true&quiesce=true", memory: true, quiesce: true},
+		{id: href + "?memory=false&quiesce=1", quiesce: true},
+		{id: "vm-1", err: true},
+		{id: href + "?memory=yes", err: true},
+		{id: href + "?size=1", err: true},
+		{id: href + "?memory=true&memory=false", err: true},
+	}
+
+	for _, c := range cases {
+		vmHREF, memory, quiesce, err := parseVMSnapshotImportID(c.id)
+		if c.err {
+			if err == nil {
+				t.Errorf("expected an error for %q", c.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", c.id, err)
+			continue
+		}
+		if vmHREF != href || memory != c.memory || quiesce != c.quiesce {
+			t.Errorf("expected %s memory=%t quiesce=%t for %q, got %s memory=%t quiesce=%t",
+				href, c.memory, c.quiesce, c.id, vmHREF, memory, quiesce)
+		}
+	}
+}
+
+func TestResourceVcdVMSnapshotImport(t *testing.T) {
+	href := "https://vcd.example.com/api/vApp/vm-1"
+
+	d := resourceVcdVMSnapshot().TestResourceData()
+	d.SetId(href + "?memory=true&quiesce=true")
+
+	imported, err := resourceVcdVMSnapshotImport(d, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 {
+		t.Fatalf("expected a single resource, got %d", len(imported))
+	}
+
+	d = imported[0]
+	if d.Id() != href || d.Get("vm_href").(string) != href {
+		t.Errorf("expected the resource to be identified by %s, got id %s and vm_href %s", href, d.Id(), d.Get("vm_href"))
+	}
+	if !d.Get("memory").(bool) || !d.Get("quiesce").(bool) {
+		t.Errorf("expected memory and quiesce to be taken from the import ID, got memory=%t quiesce=%t", d.Get("memory"), d.Get("quiesce"))
+	}
+
+	d = resourceVcdVMSnapshot().TestResourceData()
+	d.SetId("vm-1")
+	if _, err := resourceVcdVMSnapshotImport(d, nil); err == nil {
+		t.Errorf("expected an error for an import ID which is not an HREF")
+	}
+}
+
+var testAccCheckVcdVMSnapshot_basic0 = fmt.Sprintf(`
+%s
+resource "vcd_vm_snapshot" "test-snapshot" {
+  vm_href        = "${vcd_vm.test-vm.href}"
+  revert_trigger = "1"
+}
+`, testAccCheckVcdVm_single)
+
+var testAccCheckVcdVMSnapshot_basic1 = fmt.Sprintf(`
+%s
+resource "vcd_vm_snapshot" "test-snapshot" {
+  vm_href        = "${vcd_vm.test-vm.href}"
+  revert_trigger = "2"
+}
+`, testAccCheckVcdVm_single)
@@ -1124,3 +1124,33 @@ resource "vcd_vm" "test-vm"    {
   }
 }
 `, testAccCheckVcdVApp_multi_nic_vapp)
+
+// testAccCheckVcdVm_single is a vApp with a single VM, used by the tests of
+// the resources which depend on a VM
+const testAccCheckVcdVm_single = `
+resource "vcd_vapp" "test-vapp" {
+  name     = "kdalby-dev-vapp-test-only-vm-single"
+
+  organization_network = [
+    "FCI-IRT_ISN6_ORG-SRV",
+  ]
+}
+
+resource "vcd_vm" "test-vm"    {
+  name          = "test"
+  vapp_href     = "${vcd_vapp.test-vapp.id}"
+  catalog_name  = "BETA_PUBLIC_IT_DEPARTMENT"
+  template_name = "Ubuntu_Server_16.04"
+  memory        = 512
+  cpus          = 1
+  power_on      = true
+  storage_profile = "Silver"
+
+  network {
+    name               = "FCI-IRT_ISN6_ORG-SRV"
+    ip_allocation_mode = "POOL"
+    is_primary         = true
+    adapter_type       = "VMXNET3"
+  }
+}
+`